@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// AlarmManager provides access to the vCenter alarm manager
+type AlarmManager struct {
+	object.Common
+}
+
+// NewAlarmManager returns the alarm manager of the given client.
+// object.ErrNotSupported is returned if the endpoint has no alarm manager.
+func NewAlarmManager(c *vim25.Client) (*AlarmManager, error) {
+	if c.ServiceContent.AlarmManager == nil {
+		return nil, object.ErrNotSupported
+	}
+
+	return &AlarmManager{
+		Common: object.NewCommon(c, *c.ServiceContent.AlarmManager),
+	}, nil
+}
+
+// GetAlarms returns the alarms defined on the given entity or all alarms if
+// entity is nil
+func (m AlarmManager) GetAlarms(ctx context.Context, entity *types.ManagedObjectReference) ([]types.ManagedObjectReference, error) {
+	req := types.GetAlarm{
+		This:   m.Reference(),
+		Entity: entity,
+	}
+
+	res, err := methods.GetAlarm(ctx, m.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Returnval, nil
+}
+
+// GetAlarmState returns the state of the alarms triggered on the given entity
+func (m AlarmManager) GetAlarmState(ctx context.Context, entity types.ManagedObjectReference) ([]types.AlarmState, error) {
+	req := types.GetAlarmState{
+		This:   m.Reference(),
+		Entity: entity,
+	}
+
+	res, err := methods.GetAlarmState(ctx, m.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Returnval, nil
+}
+
+// AcknowledgeAlarm acknowledges the alarm triggered on the given entity
+func (m AlarmManager) AcknowledgeAlarm(ctx context.Context, alarm, entity types.ManagedObjectReference) error {
+	req := types.AcknowledgeAlarm{
+		This:   m.Reference(),
+		Alarm:  alarm,
+		Entity: entity,
+	}
+
+	_, err := methods.AcknowledgeAlarm(ctx, m.Client(), &req)
+	return err
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewAlarmManager(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		m, err := NewAlarmManager(vimclient)
+		assert.NilError(t, err)
+		assert.Equal(t, m.Reference(), *vimclient.ServiceContent.AlarmManager)
+
+		c := *vimclient
+		c.ServiceContent.AlarmManager = nil
+		_, err = NewAlarmManager(&c)
+		assert.ErrorIs(t, err, object.ErrNotSupported)
+
+		return nil
+	})
+}
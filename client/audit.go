@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// AuditOutcome is the outcome of an audited call
+type AuditOutcome string
+
+const (
+	// AuditSuccess indicates that the call succeeded
+	AuditSuccess AuditOutcome = "success"
+	// AuditFailure indicates that the call failed or was rejected
+	AuditFailure AuditOutcome = "failure"
+)
+
+// AuditRecord describes a call which might modify the inventory
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Host is the vCenter host
+	Host string `json:"host"`
+	API  API    `json:"api"`
+	// Method is the SOAP method, e.g. PowerOffVM_Task, or the REST method and
+	// path, e.g. "POST /rest/com/vmware/cis/tagging/tag"
+	Method string `json:"method"`
+	// OperationID is the vCenter operation ID (opID) of the request
+	OperationID string `json:"opID,omitempty"`
+	// Target is the managed object the SOAP method was invoked on, e.g.
+	// "VirtualMachine:vm-42", and empty for REST requests
+	Target string `json:"target,omitempty"`
+	// Labels are the labels set with ContextWithAuditLabels
+	Labels  map[string]string `json:"labels,omitempty"`
+	Outcome AuditOutcome      `json:"outcome"`
+	// Error is the reason of a failure
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink receives audit records. Implementations must be safe for
+// concurrent use.
+type AuditSink interface {
+	Audit(ctx context.Context, r AuditRecord) error
+}
+
+// AuditSinkFunc is an adapter to allow the use of ordinary functions as
+// AuditSink
+type AuditSinkFunc func(ctx context.Context, r AuditRecord) error
+
+// Audit implements AuditSink
+func (f AuditSinkFunc) Audit(ctx context.Context, r AuditRecord) error {
+	return f(ctx, r)
+}
+
+// LoggerAuditSink returns an AuditSink which logs the records with the given
+// logger
+func LoggerAuditSink(l *zap.Logger) AuditSink {
+	return AuditSinkFunc(func(_ context.Context, r AuditRecord) error {
+		fields := []zap.Field{
+			zap.Time("time", r.Time),
+			zap.String("host", r.Host),
+			zap.String("api", string(r.API)),
+			zap.String("method", r.Method),
+			zap.String("opID", r.OperationID),
+			zap.String("target", r.Target),
+			zap.Any("labels", r.Labels),
+			zap.String("outcome", string(r.Outcome)),
+		}
+		if r.Error != "" {
+			fields = append(fields, zap.String("error", r.Error))
+		}
+
+		l.Info("audit", fields...)
+		return nil
+	})
+}
+
+// JSONAuditSink returns an AuditSink which writes the records as JSON lines
+// to w, e.g. a file opened with os.O_APPEND
+func JSONAuditSink(w io.Writer) AuditSink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	return AuditSinkFunc(func(_ context.Context, r AuditRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return enc.Encode(r)
+	})
+}
+
+type auditLabelsKey struct{}
+
+// ContextWithAuditLabels returns a child context of ctx with the given labels
+// added to the audit records of calls made with the context. Labels override
+// labels with the same key set on ctx.
+func ContextWithAuditLabels(ctx context.Context, labels map[string]string) context.Context {
+	merged := make(map[string]string)
+	for k, v := range auditLabels(ctx) {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, auditLabelsKey{}, merged)
+}
+
+// auditLabels returns the labels set on ctx
+func auditLabels(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(auditLabelsKey{}).(map[string]string)
+	return labels
+}
+
+// audit sends the record to the sink and logs sink errors, i.e. a failing
+// sink does not fail the call
+func audit(ctx context.Context, sink AuditSink, r AuditRecord) {
+	r.Labels = auditLabels(ctx)
+	r.OperationID = OperationID(ctx)
+	if err := sink.Audit(ctx, r); err != nil {
+		logger.Get(ctx).Error("write audit record", zap.String("method", r.Method), zap.Error(err))
+	}
+}
+
+// soapTarget returns the managed object reference of the _this parameter of
+// the SOAP request, e.g. "VirtualMachine:vm-42", or an empty string
+func soapTarget(req soap.HasFault) string {
+	v := reflect.ValueOf(req)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ""
+	}
+
+	body := v.Elem()
+	if body.Kind() != reflect.Struct {
+		return ""
+	}
+
+	r := body.FieldByName("Req")
+	if !r.IsValid() || r.Kind() != reflect.Ptr || r.IsNil() {
+		return ""
+	}
+
+	this := r.Elem().FieldByName("This")
+	if !this.IsValid() {
+		return ""
+	}
+
+	ref, ok := this.Interface().(types.ManagedObjectReference)
+	if !ok {
+		return ""
+	}
+	return ref.String()
+}
+
+// auditSOAP returns a soap.RoundTripper which records SOAP methods which might
+// modify the inventory, i.e. methods rejected in read-only mode
+func auditSOAP(rt soap.RoundTripper, sink AuditSink, host string) soap.RoundTripper {
+	return soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		method := soapMethod(req)
+		if isReadOnlyMethod(method) {
+			return rt.RoundTrip(ctx, req, res)
+		}
+
+		r := AuditRecord{
+			Time:    time.Now().UTC(),
+			Host:    host,
+			API:     APISOAP,
+			Method:  method,
+			Target:  soapTarget(req),
+			Outcome: AuditSuccess,
+		}
+
+		err := rt.RoundTrip(ctx, req, res)
+		if err != nil {
+			r.Outcome = AuditFailure
+			r.Error = err.Error()
+		}
+		audit(ctx, sink, r)
+
+		return err
+	})
+}
+
+// auditREST returns a http.RoundTripper which records REST requests which
+// might modify the inventory, i.e. requests rejected in read-only mode
+func auditREST(rt http.RoundTripper, sink AuditSink, host string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if isReadOnlyRequest(req) {
+			return rt.RoundTrip(req)
+		}
+
+		r := AuditRecord{
+			Time:    time.Now().UTC(),
+			Host:    host,
+			API:     APIREST,
+			Method:  req.Method + " " + req.URL.Path,
+			Outcome: AuditSuccess,
+		}
+
+		res, err := rt.RoundTrip(req)
+		switch {
+		case err != nil:
+			r.Outcome = AuditFailure
+			r.Error = err.Error()
+		case res.StatusCode >= http.StatusBadRequest:
+			r.Outcome = AuditFailure
+			r.Error = res.Status
+		}
+		audit(req.Context(), sink, r)
+
+		return res, err
+	})
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/v3/assert"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+func Test_soapTarget(t *testing.T) {
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"}
+
+	assert.Equal(t, soapTarget(&methods.PowerOffVM_TaskBody{Req: &types.PowerOffVM_Task{This: vm}}), "VirtualMachine:vm-42")
+	assert.Equal(t, soapTarget(&methods.PowerOffVM_TaskBody{}), "")
+	assert.Equal(t, soapTarget((*methods.PowerOffVM_TaskBody)(nil)), "")
+}
+
+func TestContextWithAuditLabels(t *testing.T) {
+	ctx := ContextWithAuditLabels(context.Background(), map[string]string{"job": "cleanup", "user": "alice"})
+	ctx = ContextWithAuditLabels(ctx, map[string]string{"user": "bob"})
+
+	assert.DeepEqual(t, auditLabels(ctx), map[string]string{"job": "cleanup", "user": "bob"})
+	assert.Assert(t, auditLabels(context.Background()) == nil)
+}
+
+func TestLoggerAuditSink(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	sink := LoggerAuditSink(zap.New(core))
+
+	err := sink.Audit(context.Background(), AuditRecord{
+		Time:    time.Now(),
+		API:     APISOAP,
+		Method:  "PowerOffVM_Task",
+		Target:  "VirtualMachine:vm-42",
+		Outcome: AuditFailure,
+		Error:   "rejected",
+	})
+	assert.NilError(t, err)
+
+	entries := logs.FilterMessage("audit").All()
+	assert.Equal(t, len(entries), 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, fields["method"], "PowerOffVM_Task")
+	assert.Equal(t, fields["target"], "VirtualMachine:vm-42")
+	assert.Equal(t, fields["outcome"], "failure")
+	assert.Equal(t, fields["error"], "rejected")
+}
+
+func TestNewClientAudit(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		var buf bytes.Buffer
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithAudit(JSONAuditSink(&buf)),
+		)
+		assert.NilError(t, err)
+
+		vm, err := c.Finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+		assert.NilError(t, err)
+
+		// reads are not recorded
+		_, err = vm.PowerState(ctx)
+		assert.NilError(t, err)
+		_, err = c.Tags.GetTags(ctx)
+		assert.NilError(t, err)
+
+		labeled := ContextWithAuditLabels(ctx, map[string]string{"job": "power-off"})
+		task, err := vm.PowerOff(labeled)
+		assert.NilError(t, err)
+		assert.NilError(t, task.Wait(ctx))
+
+		_, err = c.Tags.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "SINGLE"})
+		assert.NilError(t, err)
+
+		missing := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-missing"}
+		_, err = methods.Destroy_Task(ctx, c.SOAP.Client, &types.Destroy_Task{This: missing})
+		assert.Assert(t, err != nil)
+
+		assert.NilError(t, c.Close(ctx))
+
+		var records []AuditRecord
+		s := bufio.NewScanner(&buf)
+		for s.Scan() {
+			var r AuditRecord
+			assert.NilError(t, json.Unmarshal(s.Bytes(), &r))
+			records = append(records, r)
+		}
+		assert.NilError(t, s.Err())
+		assert.Equal(t, len(records), 3, buf.String())
+
+		assert.Equal(t, records[0].API, APISOAP)
+		assert.Equal(t, records[0].Method, "PowerOffVM_Task")
+		assert.Equal(t, records[0].Target, vm.Reference().String())
+		assert.DeepEqual(t, records[0].Labels, map[string]string{"job": "power-off"})
+		assert.Equal(t, records[0].Outcome, AuditSuccess)
+		assert.Equal(t, records[0].Host, vimclient.URL().Host)
+		assert.Assert(t, records[0].OperationID != "")
+		assert.Assert(t, !records[0].Time.IsZero())
+
+		assert.Equal(t, records[1].API, APIREST)
+		assert.Equal(t, records[1].Method, "POST /rest/com/vmware/cis/tagging/category")
+		assert.Equal(t, records[1].Outcome, AuditSuccess)
+
+		assert.Equal(t, records[2].Method, "Destroy_Task")
+		assert.Equal(t, records[2].Target, "VirtualMachine:vm-missing")
+		assert.Equal(t, records[2].Outcome, AuditFailure)
+		assert.Assert(t, records[2].Error != "")
+
+		return nil
+	})
+}
+
+func TestNewClientAuditSinkError(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		core, logs := observer.New(zapcore.ErrorLevel)
+		ctx = logger.Set(ctx, zap.New(core))
+
+		sink := AuditSinkFunc(func(_ context.Context, _ AuditRecord) error {
+			return errors.New("sink unavailable")
+		})
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithAudit(sink),
+		)
+		assert.NilError(t, err)
+
+		vm, err := c.Finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+		assert.NilError(t, err)
+
+		// a failing sink does not fail the call
+		_, err = vm.PowerOff(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, logs.FilterMessage("write audit record").Len(), 1)
+
+		assert.NilError(t, c.Close(ctx))
+		return nil
+	})
+}
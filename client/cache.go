@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+const sessionCacheFileMode = 0o600
+
+// sessionCache persists the SOAP session cookies and the REST session ID in a
+// file to reuse vCenter sessions across restarts
+type sessionCache struct {
+	mu   sync.Mutex
+	path string
+}
+
+// cachedSession is the file format of the session cache. Sessions are only
+// reused for the same vCenter Server host and username.
+type cachedSession struct {
+	Host     string         `json:"host"`
+	Username string         `json:"username"`
+	SOAP     []*http.Cookie `json:"soap,omitempty"`
+	REST     string         `json:"rest,omitempty"`
+}
+
+func newSessionCache(path string) *sessionCache {
+	if path == "" {
+		return nil
+	}
+	return &sessionCache{path: path}
+}
+
+// load returns the cached session for the given host and username. Returns an
+// empty session if the cache does not exist or does not match.
+func (s *sessionCache) load(host, username string) cachedSession {
+	empty := cachedSession{Host: host, Username: username}
+
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return empty
+	}
+
+	var cs cachedSession
+	if err = json.Unmarshal(b, &cs); err != nil {
+		return empty
+	}
+
+	if cs.Host != host || cs.Username != username {
+		return empty
+	}
+
+	return cs
+}
+
+// update modifies the cached session for the given host and username with f
+// and atomically writes the cache file with restrictive permissions
+func (s *sessionCache) update(host, username string, f func(cs *cachedSession)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs := s.load(host, username)
+	f(&cs)
+
+	b, err := json.Marshal(cs)
+	if err != nil {
+		return fmt.Errorf("marshal session cache: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("create session cache: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err = tmp.Chmod(sessionCacheFileMode); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("set session cache permissions: %w", err)
+	}
+
+	if _, err = tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write session cache: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("close session cache: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write session cache: %w", err)
+	}
+
+	return nil
+}
+
+// restoreSOAP sets the cached session cookies on the SOAP client and returns
+// true if the cached session is still valid
+func (s *sessionCache) restoreSOAP(ctx context.Context, vc *vim25.Client, m *session.Manager, username string) bool {
+	u := vc.URL()
+	cs := s.load(u.Host, username)
+	if len(cs.SOAP) == 0 {
+		return false
+	}
+
+	vc.Jar.SetCookies(u, cs.SOAP)
+	us, err := m.UserSession(ctx)
+	if err != nil || us == nil {
+		logger.Get(ctx).Debug("cached SOAP session invalid", zap.Error(err))
+		return false
+	}
+
+	logger.Get(ctx).Debug("reusing cached SOAP session", zap.String("path", s.path))
+	return true
+}
+
+// saveSOAP stores the session cookies of the SOAP client
+func (s *sessionCache) saveSOAP(vc *vim25.Client, username string) error {
+	u := vc.URL()
+	cookies := vc.Jar.Cookies(u)
+	if len(cookies) == 0 {
+		return errors.New("no SOAP session cookies found")
+	}
+
+	return s.update(u.Host, username, func(cs *cachedSession) {
+		cs.SOAP = cookies
+	})
+}
+
+// restoreREST sets the cached session ID on the REST client and returns true
+// if the cached session is still valid. The session is checked with
+// sessionClient, i.e. bypassing the keep-alive handler of rc which would be
+// started by the check.
+func (s *sessionCache) restoreREST(ctx context.Context, rc, sessionClient *rest.Client, username string) bool {
+	cs := s.load(rc.URL().Host, username)
+	if cs.REST == "" {
+		return false
+	}
+
+	sessionClient.SessionID(cs.REST)
+	rs, err := sessionClient.Session(ctx)
+	if err != nil || rs == nil {
+		logger.Get(ctx).Debug("cached REST session invalid", zap.Error(err))
+		sessionClient.SessionID("")
+		return false
+	}
+
+	rc.SessionID(cs.REST)
+	logger.Get(ctx).Debug("reusing cached REST session", zap.String("path", s.path))
+	return true
+}
+
+// saveREST stores the session ID of the REST client
+func (s *sessionCache) saveREST(rc *rest.Client, username string) error {
+	id := rc.SessionID()
+	if id == "" {
+		return errors.New("no REST session ID found")
+	}
+
+	return s.update(rc.URL().Host, username, func(cs *cachedSession) {
+		cs.REST = id
+	})
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewClientSessionCache(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		path := filepath.Join(t.TempDir(), "session.json")
+
+		opts := []Option{
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithSessionCache(path),
+		}
+
+		c, err := New(ctx, opts...)
+		assert.NilError(t, err)
+
+		first, err := c.SOAP.SessionManager.UserSession(ctx)
+		assert.NilError(t, err)
+		restID := c.REST.SessionID()
+
+		fi, err := os.Stat(path)
+		assert.NilError(t, err)
+		assert.Equal(t, fi.Mode().Perm(), os.FileMode(sessionCacheFileMode))
+
+		// sessions are kept when the session cache is enabled
+		assert.NilError(t, c.Logout())
+
+		t.Run("reuses cached sessions", func(t *testing.T) {
+			c, err := New(ctx, opts...)
+			assert.NilError(t, err)
+
+			s, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			assert.Equal(t, s.Key, first.Key)
+			assert.Equal(t, c.REST.SessionID(), restID)
+
+			assert.NilError(t, c.Logout())
+		})
+
+		t.Run("creates new sessions when cached sessions are invalid", func(t *testing.T) {
+			err := session.NewManager(vimclient).TerminateSession(ctx, []string{first.Key})
+			assert.NilError(t, err)
+
+			c, err := New(ctx, opts...)
+			assert.NilError(t, err)
+
+			s, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, s.Key != first.Key)
+
+			cs := c.cache.load(vimclient.URL().Host, "user")
+			assert.Assert(t, len(cs.SOAP) > 0)
+			assert.Equal(t, cs.REST, c.REST.SessionID())
+
+			assert.NilError(t, c.Logout())
+		})
+
+		t.Run("ignores cached sessions of other users", func(t *testing.T) {
+			c, err := New(ctx,
+				WithURL(vimclient.URL().String()),
+				WithInsecure(true),
+				WithCredentials("other", "pass"),
+				WithSessionCache(path),
+			)
+			assert.NilError(t, err)
+
+			s, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			assert.Equal(t, s.UserName, "other")
+
+			assert.NilError(t, c.Logout())
+		})
+
+		t.Run("stops REST keep-alive when login fails", func(t *testing.T) {
+			c, err := New(ctx, opts...)
+			assert.NilError(t, err)
+
+			rc := rest.NewClient(vimclient)
+			rc.SessionID(c.REST.SessionID())
+			assert.NilError(t, rc.Logout(ctx))
+			assert.NilError(t, c.Close(ctx))
+			waitForKeepaliveGoroutines(t, 0)
+
+			// the cached SOAP session is reused and the REST login with the
+			// invalid cached session and credentials fails
+			_, err = New(ctx,
+				WithURL(vimclient.URL().String()),
+				WithInsecure(true),
+				WithCredentials("user", ""),
+				WithSessionCache(path),
+			)
+			assert.ErrorIs(t, err, ErrAuthentication)
+			waitForKeepaliveGoroutines(t, 0)
+		})
+
+		return nil
+	})
+}
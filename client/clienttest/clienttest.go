@@ -0,0 +1,209 @@
+// Package clienttest provides an in-process vCenter Server simulator to test
+// code using the client package.
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator" // register REST API endpoints
+
+	"github.com/embano1/vsphere/client"
+)
+
+const (
+	// DefaultUsername is the username written to the secret path
+	DefaultUsername = "user"
+	// DefaultPassword is the password written to the secret path
+	DefaultPassword = "pass"
+)
+
+// Option configures the simulator and client created by New
+type Option func(o *options) error
+
+type options struct {
+	model      *simulator.Model
+	username   string
+	password   string
+	clientOpts []client.Option
+}
+
+// WithModel uses the given simulator model to create the inventory, e.g.
+// simulator.ESX() or simulator.VPX() with additional hosts and virtual
+// machines. The model must not be created yet. The default is simulator.VPX().
+func WithModel(model *simulator.Model) Option {
+	return func(o *options) error {
+		if model == nil {
+			return errors.New("model must not be nil")
+		}
+		o.model = model
+		return nil
+	}
+}
+
+// WithCredentials sets the username and password written to the secret path
+func WithCredentials(username, password string) Option {
+	return func(o *options) error {
+		if username == "" || password == "" {
+			return errors.New("username and password must be specified")
+		}
+		o.username = username
+		o.password = password
+		return nil
+	}
+}
+
+// WithClientOptions passes additional options to client.New. They are applied
+// after the environment, i.e. override it.
+func WithClientOptions(opts ...client.Option) Option {
+	return func(o *options) error {
+		o.clientOpts = append(o.clientOpts, opts...)
+		return nil
+	}
+}
+
+// New starts a simulator with the configured inventory, writes the
+// credentials to a temporary secret path, sets the VCENTER_URL,
+// VCENTER_INSECURE and VCENTER_SECRET_PATH environment variables and returns a
+// client created with client.New. The returned cleanup function closes the
+// client, stops the simulator, removes the secret path and restores the
+// environment. It must be called when the client is no longer used.
+//
+// New modifies the process environment and must not be used in parallel
+// tests.
+func New(ctx context.Context, opts ...Option) (*client.Client, func(), error) {
+	o := options{
+		username: DefaultUsername,
+		password: DefaultPassword,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if o.model == nil {
+		o.model = simulator.VPX()
+	}
+
+	var cleanups []func() error
+	cleanup := func() {
+		// reverse order of setup
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			_ = cleanups[i]()
+		}
+	}
+
+	if err := o.model.Create(); err != nil {
+		o.model.Remove()
+		return nil, nil, fmt.Errorf("create simulator model: %w", err)
+	}
+	cleanups = append(cleanups, func() error {
+		o.model.Remove()
+		return nil
+	})
+
+	o.model.Service.RegisterEndpoints = true
+	s := o.model.Service.NewServer()
+	cleanups = append(cleanups, func() error {
+		s.Close()
+		return nil
+	})
+
+	dir, err := writeSecret(o.username, o.password)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	cleanups = append(cleanups, func() error {
+		return os.RemoveAll(dir)
+	})
+
+	env := map[string]string{
+		"VCENTER_URL":         s.URL.String(),
+		"VCENTER_INSECURE":    "true",
+		"VCENTER_SECRET_PATH": dir,
+	}
+	restore, err := setEnv(env)
+	cleanups = append(cleanups, restore)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	c, err := client.New(ctx, append([]client.Option{client.WithEnv()}, o.clientOpts...)...)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("create client: %w", err)
+	}
+	cleanups = append(cleanups, func() error {
+		return c.Close(context.Background())
+	})
+
+	return c, cleanup, nil
+}
+
+// writeSecret writes the username and password files to a new temporary
+// directory and returns its path
+func writeSecret(username, password string) (string, error) {
+	dir, err := os.MkdirTemp("", "clienttest")
+	if err != nil {
+		return "", fmt.Errorf("create secret directory: %w", err)
+	}
+
+	files := map[string]string{
+		"username": username,
+		"password": password,
+	}
+	for name, v := range files {
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(v), 0o600); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", fmt.Errorf("write %s file: %w", name, err)
+		}
+	}
+
+	return dir, nil
+}
+
+// setEnv sets the given environment variables and returns a function which
+// restores the previous values
+func setEnv(env map[string]string) (func() error, error) {
+	type prev struct {
+		value string
+		ok    bool
+	}
+	previous := make(map[string]prev, len(env))
+
+	restore := func() error {
+		var result error
+		for k, p := range previous {
+			var err error
+			if p.ok {
+				err = os.Setenv(k, p.value)
+			} else {
+				err = os.Unsetenv(k)
+			}
+			if err != nil {
+				result = multierror.Append(result, fmt.Errorf("restore %q env var: %w", k, err))
+			}
+		}
+		return result
+	}
+
+	for k, v := range env {
+		value, ok := os.LookupEnv(k)
+		previous[k] = prev{value: value, ok: ok}
+
+		if err := os.Setenv(k, v); err != nil {
+			return restore, fmt.Errorf("set %q env var: %w", k, err)
+		}
+	}
+
+	return restore, nil
+}
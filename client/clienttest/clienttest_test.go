@@ -0,0 +1,92 @@
+package clienttest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"gotest.tools/v3/assert"
+
+	"github.com/embano1/vsphere/client"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates client for default inventory", func(t *testing.T) {
+		t.Setenv("VCENTER_URL", "https://previous.local/sdk")
+
+		c, cleanup, err := New(ctx)
+		assert.NilError(t, err)
+
+		assert.Assert(t, c.HasREST())
+		assert.Assert(t, os.Getenv("VCENTER_URL") != "https://previous.local/sdk")
+
+		secretPath := os.Getenv("VCENTER_SECRET_PATH")
+		creds, err := client.FileCredentials(secretPath).Credentials(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, creds.Username, DefaultUsername)
+
+		vms, err := c.Finder.VirtualMachineList(ctx, "/DC0/vm/*")
+		assert.NilError(t, err)
+		assert.Equal(t, len(vms), 4)
+
+		cleanup()
+
+		assert.Equal(t, os.Getenv("VCENTER_URL"), "https://previous.local/sdk")
+		_, ok := os.LookupEnv("VCENTER_SECRET_PATH")
+		assert.Assert(t, !ok)
+		_, err = os.Stat(secretPath)
+		assert.Assert(t, os.IsNotExist(err))
+	})
+
+	t.Run("creates client for custom inventory", func(t *testing.T) {
+		model := simulator.VPX()
+		model.Datacenter = 2
+		model.Machine = 1
+
+		c, cleanup, err := New(ctx,
+			WithModel(model),
+			WithCredentials("administrator@vsphere.local", "secret"),
+			WithClientOptions(client.WithDatacenter("DC1")),
+		)
+		assert.NilError(t, err)
+		defer cleanup()
+
+		vms, err := c.Finder.VirtualMachineList(ctx, "*")
+		assert.NilError(t, err)
+		assert.Equal(t, len(vms), 2)
+		assert.Equal(t, vms[0].InventoryPath, "/DC1/vm/DC1_H0_VM0")
+
+		s, err := c.SOAP.SessionManager.UserSession(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, s.UserName, "administrator@vsphere.local")
+	})
+
+	t.Run("creates SOAP-only client for ESXi", func(t *testing.T) {
+		c, cleanup, err := New(ctx, WithModel(simulator.ESX()))
+		assert.NilError(t, err)
+		defer cleanup()
+
+		assert.Assert(t, !c.HasREST())
+		assert.Assert(t, !c.Info().IsVC())
+	})
+
+	t.Run("fails with invalid options", func(t *testing.T) {
+		_, _, err := New(ctx, WithModel(nil))
+		assert.ErrorContains(t, err, "model must not be nil")
+
+		_, _, err = New(ctx, WithCredentials("user", ""))
+		assert.ErrorContains(t, err, "username and password must be specified")
+	})
+
+	t.Run("cleans up if client cannot be created", func(t *testing.T) {
+		_, cleanup, err := New(ctx, WithClientOptions(client.WithDatacenter("invalid")))
+		assert.ErrorContains(t, err, "create client")
+		assert.Assert(t, cleanup == nil)
+
+		_, ok := os.LookupEnv("VCENTER_SECRET_PATH")
+		assert.Assert(t, !ok)
+	})
+}
@@ -0,0 +1,26 @@
+package clienttest_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/embano1/vsphere/client/clienttest"
+)
+
+func ExampleNew() {
+	ctx := context.Background()
+
+	c, cleanup, err := clienttest.New(ctx)
+	if err != nil {
+		panic(err)
+	}
+	defer cleanup()
+
+	vms, err := c.Finder.VirtualMachineList(ctx, "/DC0/vm/*")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(len(vms), "virtual machines")
+	// Output: 4 virtual machines
+}
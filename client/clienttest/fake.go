@@ -0,0 +1,315 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/history"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/embano1/vsphere/client"
+	vsphereevent "github.com/embano1/vsphere/event"
+)
+
+// ErrNotFound is returned by the fakes if the requested object does not exist
+var ErrNotFound = errors.New("not found")
+
+var (
+	_ vsphereevent.EventSource = (*FakeEventSource)(nil)
+	_ client.TagReader         = (*FakeTagReader)(nil)
+	_ client.TaskWaiter        = (*FakeTask)(nil)
+)
+
+// FakeEventSource is an in-memory event.EventSource. Collectors return all
+// events added with Add in order, including events added after the collector
+// was created. Only the EventTypeId field of the filter spec is applied.
+// Collectors support ReadNextEvents and Destroy.
+type FakeEventSource struct {
+	mu         sync.Mutex
+	events     []types.BaseEvent
+	collectors map[string]*fakeCollector
+	nextID     int
+}
+
+type fakeCollector struct {
+	filter types.EventFilterSpec
+	// pos is the index of the next event to read
+	pos int
+}
+
+// NewFakeEventSource returns a FakeEventSource with the given events
+func NewFakeEventSource(events ...types.BaseEvent) *FakeEventSource {
+	return &FakeEventSource{
+		events:     events,
+		collectors: make(map[string]*fakeCollector),
+	}
+}
+
+// Add appends events to the event history
+func (s *FakeEventSource) Add(events ...types.BaseEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+}
+
+// CreateCollectorForEvents implements event.EventSource
+func (s *FakeEventSource) CreateCollectorForEvents(_ context.Context, filter types.EventFilterSpec) (*event.HistoryCollector, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	ref := types.ManagedObjectReference{Type: "EventHistoryCollector", Value: "session[fake]" + strconv.Itoa(s.nextID)}
+	s.collectors[ref.Value] = &fakeCollector{filter: filter}
+
+	vc := &vim25.Client{RoundTripper: &collectorRoundTripper{src: s}}
+	return &event.HistoryCollector{Collector: history.NewCollector(vc, ref)}, nil
+}
+
+// QueryEvents implements event.EventSource
+func (s *FakeEventSource) QueryEvents(_ context.Context, filter types.EventFilterSpec) ([]types.BaseEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []types.BaseEvent
+	for _, e := range s.events {
+		if matches(filter, e) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// readNext returns up to maxCount matching events of the given collector
+func (s *FakeEventSource) readNext(ref types.ManagedObjectReference, maxCount int32) ([]types.BaseEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.collectors[ref.Value]
+	if !ok {
+		return nil, soap.WrapVimFault(&types.ManagedObjectNotFound{Obj: ref})
+	}
+
+	var events []types.BaseEvent
+	for ; c.pos < len(s.events) && int32(len(events)) < maxCount; c.pos++ {
+		if matches(c.filter, s.events[c.pos]) {
+			events = append(events, s.events[c.pos])
+		}
+	}
+	return events, nil
+}
+
+func (s *FakeEventSource) destroy(ref types.ManagedObjectReference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.collectors[ref.Value]; !ok {
+		return soap.WrapVimFault(&types.ManagedObjectNotFound{Obj: ref})
+	}
+	delete(s.collectors, ref.Value)
+	return nil
+}
+
+// matches returns true if the event type matches the EventTypeId filter
+func matches(filter types.EventFilterSpec, e types.BaseEvent) bool {
+	if len(filter.EventTypeId) == 0 {
+		return true
+	}
+
+	details := vsphereevent.GetDetails(e)
+	for _, id := range filter.EventTypeId {
+		if id == details.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// collectorRoundTripper serves the SOAP methods of the fake event collectors
+type collectorRoundTripper struct {
+	src *FakeEventSource
+}
+
+func (rt *collectorRoundTripper) RoundTrip(_ context.Context, req, res soap.HasFault) error {
+	switch req := req.(type) {
+	case *methods.ReadNextEventsBody:
+		events, err := rt.src.readNext(req.Req.This, req.Req.MaxCount)
+		if err != nil {
+			return err
+		}
+		res.(*methods.ReadNextEventsBody).Res = &types.ReadNextEventsResponse{Returnval: events}
+	case *methods.DestroyCollectorBody:
+		if err := rt.src.destroy(req.Req.This); err != nil {
+			return err
+		}
+		res.(*methods.DestroyCollectorBody).Res = &types.DestroyCollectorResponse{}
+	default:
+		return fmt.Errorf("method %T not supported by fake event collector", req)
+	}
+	return nil
+}
+
+// FakeTagReader is an in-memory client.TagReader. Tags and categories are
+// looked up by ID or name.
+type FakeTagReader struct {
+	mu         sync.Mutex
+	categories []tags.Category
+	tags       []tags.Tag
+	// attached maps tag IDs to the objects the tag is attached to
+	attached map[string][]types.ManagedObjectReference
+}
+
+// NewFakeTagReader returns an empty FakeTagReader
+func NewFakeTagReader() *FakeTagReader {
+	return &FakeTagReader{
+		attached: make(map[string][]types.ManagedObjectReference),
+	}
+}
+
+// AddCategory adds the given categories
+func (r *FakeTagReader) AddCategory(categories ...tags.Category) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.categories = append(r.categories, categories...)
+}
+
+// AddTag adds the given tags
+func (r *FakeTagReader) AddTag(t ...tags.Tag) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tags = append(r.tags, t...)
+}
+
+// Attach attaches the tag with the given ID to the objects
+func (r *FakeTagReader) Attach(tagID string, refs ...mo.Reference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, err := r.tag(tagID)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		r.attached[t.ID] = append(r.attached[t.ID], ref.Reference())
+	}
+	return nil
+}
+
+// GetTag implements client.TagReader
+func (r *FakeTagReader) GetTag(_ context.Context, id string) (*tags.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tag(id)
+}
+
+// GetTags implements client.TagReader
+func (r *FakeTagReader) GetTags(_ context.Context) ([]tags.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]tags.Tag(nil), r.tags...), nil
+}
+
+// GetCategory implements client.TagReader
+func (r *FakeTagReader) GetCategory(_ context.Context, id string) (*tags.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.categories {
+		if c := r.categories[i]; c.ID == id || c.Name == id {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("category %q: %w", id, ErrNotFound)
+}
+
+// GetCategories implements client.TagReader
+func (r *FakeTagReader) GetCategories(_ context.Context) ([]tags.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]tags.Category(nil), r.categories...), nil
+}
+
+// GetAttachedTags implements client.TagReader
+func (r *FakeTagReader) GetAttachedTags(_ context.Context, ref mo.Reference) ([]tags.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var attached []tags.Tag
+	for _, t := range r.tags {
+		for _, obj := range r.attached[t.ID] {
+			if obj == ref.Reference() {
+				attached = append(attached, t)
+				break
+			}
+		}
+	}
+	return attached, nil
+}
+
+// ListAttachedObjects implements client.TagReader
+func (r *FakeTagReader) ListAttachedObjects(_ context.Context, tagID string) ([]mo.Reference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, err := r.tag(tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []mo.Reference
+	for _, obj := range r.attached[t.ID] {
+		refs = append(refs, obj)
+	}
+	return refs, nil
+}
+
+// tag returns the tag with the given ID or name. The caller must hold mu.
+func (r *FakeTagReader) tag(id string) (*tags.Tag, error) {
+	for i := range r.tags {
+		if t := r.tags[i]; t.ID == id || t.Name == id {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("tag %q: %w", id, ErrNotFound)
+}
+
+// FakeTask is a client.TaskWaiter which completes immediately with Info. If
+// Err is set, the task fails with Err.
+type FakeTask struct {
+	Info types.TaskInfo
+	Err  error
+}
+
+// Wait implements client.TaskWaiter
+func (t *FakeTask) Wait(ctx context.Context) error {
+	_, err := t.WaitForResult(ctx)
+	return err
+}
+
+// WaitForResult implements client.TaskWaiter
+func (t *FakeTask) WaitForResult(ctx context.Context, _ ...progress.Sinker) (*types.TaskInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	info := t.Info
+	if t.Err != nil {
+		info.State = types.TaskInfoStateError
+		return &info, t.Err
+	}
+
+	if info.State == "" {
+		info.State = types.TaskInfoStateSuccess
+	}
+	return &info, nil
+}
@@ -0,0 +1,116 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/embano1/vsphere/event"
+)
+
+func TestFakeEventSource(t *testing.T) {
+	ctx := context.Background()
+	root := types.ManagedObjectReference{Type: "Folder", Value: "group-d1"}
+
+	src := NewFakeEventSource(
+		&types.VmPoweredOnEvent{},
+		&types.UserLoginSessionEvent{},
+		&types.VmPoweredOnEvent{},
+	)
+
+	collector, err := event.NewHistoryCollector(ctx, src, root, event.WithEventTypeID([]string{"VmPoweredOnEvent"}))
+	assert.NilError(t, err)
+
+	events, err := collector.ReadNextEvents(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+
+	src.Add(&types.VmPoweredOnEvent{}, &types.EventEx{EventTypeId: "com.vmware.cl.CreateLibraryEvent"})
+
+	events, err = collector.ReadNextEvents(ctx, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 2)
+	for _, e := range events {
+		assert.Equal(t, event.GetDetails(e).Type, "VmPoweredOnEvent")
+	}
+
+	events, err = collector.ReadNextEvents(ctx, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+
+	events, err = src.QueryEvents(ctx, types.EventFilterSpec{EventTypeId: []string{"com.vmware.cl.CreateLibraryEvent"}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+
+	assert.NilError(t, collector.Destroy(ctx))
+	_, err = collector.ReadNextEvents(ctx, 10)
+	assert.ErrorContains(t, err, "ManagedObjectNotFound")
+
+	_, err = collector.LatestPage(ctx)
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestFakeTagReader(t *testing.T) {
+	ctx := context.Background()
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+
+	r := NewFakeTagReader()
+	r.AddCategory(tags.Category{ID: "cat-1", Name: "env"})
+	r.AddTag(tags.Tag{ID: "tag-1", Name: "prod", CategoryID: "cat-1"}, tags.Tag{ID: "tag-2", Name: "dev", CategoryID: "cat-1"})
+	assert.NilError(t, r.Attach("prod", vm))
+	assert.ErrorIs(t, r.Attach("unknown", vm), ErrNotFound)
+
+	tag, err := r.GetTag(ctx, "tag-1")
+	assert.NilError(t, err)
+	assert.Equal(t, tag.Name, "prod")
+
+	_, err = r.GetTag(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	all, err := r.GetTags(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(all), 2)
+
+	category, err := r.GetCategory(ctx, "env")
+	assert.NilError(t, err)
+	assert.Equal(t, category.ID, "cat-1")
+
+	_, err = r.GetCategory(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	categories, err := r.GetCategories(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(categories), 1)
+
+	attached, err := r.GetAttachedTags(ctx, vm)
+	assert.NilError(t, err)
+	assert.Equal(t, len(attached), 1)
+	assert.Equal(t, attached[0].ID, "tag-1")
+
+	objs, err := r.ListAttachedObjects(ctx, "tag-1")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, objs, []mo.Reference{vm})
+}
+
+func TestFakeTask(t *testing.T) {
+	ctx := context.Background()
+
+	info, err := (&FakeTask{Info: types.TaskInfo{Key: "task-1"}}).WaitForResult(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, info.Key, "task-1")
+	assert.Equal(t, info.State, types.TaskInfoStateSuccess)
+
+	failed := errors.New("failed")
+	info, err = (&FakeTask{Err: failed}).WaitForResult(ctx)
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, info.State, types.TaskInfoStateError)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, (&FakeTask{}).Wait(canceled), context.Canceled)
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// DefaultUsernameEnv is the default environment variable used by
+	// EnvCredentials to retrieve the username
+	DefaultUsernameEnv = "VCENTER_USERNAME"
+	// DefaultPasswordEnv is the default environment variable used by
+	// EnvCredentials to retrieve the password
+	DefaultPasswordEnv = "VCENTER_PASSWORD"
+)
+
+// Credentials are used to authenticate against vCenter Server
+type Credentials struct {
+	Username string
+	Password string
+}
+
+func (c Credentials) userinfo() *url.Userinfo {
+	return url.UserPassword(c.Username, c.Password)
+}
+
+// String implements fmt.Stringer and omits the password
+func (c Credentials) String() string {
+	return fmt.Sprintf("{Username:%s Password:%s}", c.Username, redacted)
+}
+
+// GoString implements fmt.GoStringer and omits the password
+func (c Credentials) GoString() string {
+	return fmt.Sprintf("client.Credentials{Username:%q, Password:%q}", c.Username, redacted)
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler and omits the password
+func (c Credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("username", c.Username)
+	enc.AddString("password", redacted)
+	return nil
+}
+
+// CredentialProvider retrieves credentials to authenticate against vCenter
+// Server. Implementations must be safe for concurrent use.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialProviderFunc is an adapter to allow the use of ordinary functions
+// as CredentialProvider
+type CredentialProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials implements CredentialProvider
+func (f CredentialProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// StaticCredentials returns a CredentialProvider which always returns the
+// given username and password
+func StaticCredentials(username, password string) CredentialProvider {
+	return CredentialProviderFunc(func(_ context.Context) (Credentials, error) {
+		return Credentials{Username: username, Password: password}, nil
+	})
+}
+
+// FileCredentials returns a CredentialProvider which reads the username and
+// password from the files "username" and "password" in the given directory,
+// e.g. a mounted Kubernetes secret. The files are read on every call.
+func FileCredentials(path string) CredentialProvider {
+	return &fileCredentials{path: path}
+}
+
+type fileCredentials struct {
+	path string
+}
+
+// Credentials implements CredentialProvider
+func (f *fileCredentials) Credentials(_ context.Context) (Credentials, error) {
+	username, err := readKey(f.path, userFileKey)
+	if err != nil {
+		return Credentials{}, err
+	}
+	password, err := readKey(f.path, passwordFileKey)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return Credentials{Username: username, Password: password}, nil
+}
+
+// EnvCredentials returns a CredentialProvider which reads the username and
+// password from the given environment variables. If empty, DefaultUsernameEnv
+// and DefaultPasswordEnv are used.
+func EnvCredentials(usernameEnv, passwordEnv string) CredentialProvider {
+	if usernameEnv == "" {
+		usernameEnv = DefaultUsernameEnv
+	}
+	if passwordEnv == "" {
+		passwordEnv = DefaultPasswordEnv
+	}
+
+	return CredentialProviderFunc(func(_ context.Context) (Credentials, error) {
+		username, ok := os.LookupEnv(usernameEnv)
+		if !ok || username == "" {
+			return Credentials{}, fmt.Errorf("environment variable %q not set", usernameEnv)
+		}
+		password, ok := os.LookupEnv(passwordEnv)
+		if !ok {
+			return Credentials{}, fmt.Errorf("environment variable %q not set", passwordEnv)
+		}
+		return Credentials{Username: username, Password: password}, nil
+	})
+}
+
+// ChainCredentials returns a CredentialProvider which tries the given
+// providers in order and returns the credentials of the first provider which
+// does not return an error
+func ChainCredentials(providers ...CredentialProvider) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context) (Credentials, error) {
+		if len(providers) == 0 {
+			return Credentials{}, errors.New("no credential providers in chain")
+		}
+
+		var result error
+		for _, p := range providers {
+			creds, err := p.Credentials(ctx)
+			if err == nil {
+				return creds, nil
+			}
+			result = multierror.Append(result, err)
+		}
+
+		return Credentials{}, fmt.Errorf("retrieve credentials from chain: %w", result)
+	})
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCredentialProviders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("static credentials", func(t *testing.T) {
+		creds, err := StaticCredentials("user", "pass").Credentials(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, creds, Credentials{Username: "user", Password: "pass"})
+	})
+
+	t.Run("file credentials", func(t *testing.T) {
+		dir := tempDir(t)
+		t.Cleanup(func() {
+			assert.NilError(t, os.RemoveAll(dir))
+		})
+
+		creds, err := FileCredentials(dir).Credentials(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, creds, Credentials{Username: "user", Password: "pass"})
+
+		_, err = FileCredentials("/does/not/exist").Credentials(ctx)
+		assert.ErrorContains(t, err, "no such file")
+	})
+
+	t.Run("env credentials", func(t *testing.T) {
+		_, err := EnvCredentials("TEST_VCENTER_USER", "TEST_VCENTER_PASS").Credentials(ctx)
+		assert.ErrorContains(t, err, `"TEST_VCENTER_USER" not set`)
+
+		t.Setenv("TEST_VCENTER_USER", "user")
+		_, err = EnvCredentials("TEST_VCENTER_USER", "TEST_VCENTER_PASS").Credentials(ctx)
+		assert.ErrorContains(t, err, `"TEST_VCENTER_PASS" not set`)
+
+		t.Setenv("TEST_VCENTER_PASS", "pass")
+		creds, err := EnvCredentials("TEST_VCENTER_USER", "TEST_VCENTER_PASS").Credentials(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, creds, Credentials{Username: "user", Password: "pass"})
+
+		t.Setenv(DefaultUsernameEnv, "defaultuser")
+		t.Setenv(DefaultPasswordEnv, "defaultpass")
+		creds, err = EnvCredentials("", "").Credentials(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, creds, Credentials{Username: "defaultuser", Password: "defaultpass"})
+	})
+
+	t.Run("chain credentials", func(t *testing.T) {
+		failing := CredentialProviderFunc(func(_ context.Context) (Credentials, error) {
+			return Credentials{}, errors.New("provider failed")
+		})
+
+		_, err := ChainCredentials().Credentials(ctx)
+		assert.ErrorContains(t, err, "no credential providers")
+
+		_, err = ChainCredentials(failing, failing).Credentials(ctx)
+		assert.ErrorContains(t, err, "provider failed")
+
+		creds, err := ChainCredentials(failing, StaticCredentials("user", "pass")).Credentials(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, creds, Credentials{Username: "user", Password: "pass"})
+	})
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Classes of errors returned by the client constructors. Use errors.Is to
+// check the class of an error and errors.As with *Error to access the details.
+var (
+	// ErrConfig indicates an invalid or incomplete configuration
+	ErrConfig = errors.New("invalid configuration")
+	// ErrCredentials indicates that the credentials could not be retrieved,
+	// e.g. an unreadable secret
+	ErrCredentials = errors.New("credentials not available")
+	// ErrAuthentication indicates that vCenter Server rejected the credentials
+	ErrAuthentication = errors.New("authentication failed")
+	// ErrTLS indicates a failed verification of the server certificate
+	ErrTLS = errors.New("tls verification failed")
+	// ErrUnreachable indicates that vCenter Server could not be reached, e.g.
+	// connection refused, DNS or timeout errors
+	ErrUnreachable = errors.New("vcenter unreachable")
+)
+
+// Error is returned by the client constructors and wraps the underlying error
+// with its class
+type Error struct {
+	// Class is one of the ErrConfig, ErrCredentials, ErrAuthentication,
+	// ErrTLS or ErrUnreachable errors or nil if unknown
+	Class error
+	// Op is the failed operation, e.g. "create vsphere SOAP client"
+	Op  string
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if target is the class of the error
+func (e *Error) Is(target error) bool {
+	return e.Class != nil && target == e.Class
+}
+
+// newError returns an Error for the given operation. If class is nil, it is
+// derived from err.
+func newError(class error, op string, err error) error {
+	if class == nil {
+		class = classify(err)
+	}
+	return &Error{Class: class, Op: op, Err: err}
+}
+
+// classify returns the class of the given connection or login error or nil if
+// unknown
+func classify(err error) error {
+	switch {
+	case isAuthenticationError(err):
+		return ErrAuthentication
+	case isTLSError(err):
+		return ErrTLS
+	case isUnreachable(err):
+		return ErrUnreachable
+	default:
+		return nil
+	}
+}
+
+func isAuthenticationError(err error) bool {
+	var f types.HasFault
+	if errors.As(err, &f) {
+		if _, ok := f.Fault().(*types.InvalidLogin); ok {
+			return true
+		}
+	}
+
+	// soap faults and rest status errors are not matched by errors.As
+	for ; err != nil; err = errors.Unwrap(err) {
+		if soap.IsSoapFault(err) {
+			switch soap.ToSoapFault(err).VimFault().(type) {
+			case types.InvalidLogin, *types.InvalidLogin:
+				return true
+			}
+		}
+
+		if rest.IsStatusError(err, http.StatusUnauthorized) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTLSError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		recordHeader     tls.RecordHeaderError
+		thumbprint       *thumbprintError
+	)
+
+	return errors.As(err, &unknownAuthority) ||
+		errors.As(err, &invalidCert) ||
+		errors.As(err, &hostname) ||
+		errors.As(err, &recordHeader) ||
+		errors.As(err, &thumbprint)
+}
+
+func isUnreachable(err error) bool {
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+		netErr net.Error
+	)
+
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) {
+		return true
+	}
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"gotest.tools/v3/assert"
+)
+
+func Test_classify(t *testing.T) {
+	invalidLogin := soap.WrapVimFault(&types.InvalidLogin{})
+
+	testCases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "invalid login", err: invalidLogin, want: ErrAuthentication},
+		{name: "wrapped invalid login", err: fmt.Errorf("login: %w", invalidLogin), want: ErrAuthentication},
+		{name: "unknown authority", err: &url.Error{Op: "Post", Err: x509.UnknownAuthorityError{}}, want: ErrTLS},
+		{name: "thumbprint mismatch", err: &url.Error{Op: "Post", Err: &thumbprintError{got: []byte{1}, want: []byte{2}}}, want: ErrTLS},
+		{name: "connection refused", err: &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, want: ErrUnreachable},
+		{name: "dns", err: &net.DNSError{Err: "no such host", Name: "vcenter.local"}, want: ErrUnreachable},
+		{name: "unknown", err: errors.New("unknown"), want: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, classify(tc.err), tc.want)
+		})
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	model := simulator.VPX()
+	defer model.Remove()
+
+	err := model.Create()
+	assert.NilError(t, err)
+
+	model.Service.TLS = new(tls.Config)
+	model.Service.RegisterEndpoints = true
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	sum := sha256.Sum256(s.Certificate().Raw)
+	thumbprint := formatThumbprint(sum[:])
+	wrongThumbprint := strings.Repeat("AB:", sha256.Size-1) + "AB"
+
+	testCases := []struct {
+		name  string
+		opts  []Option
+		class error
+	}{
+		{
+			name:  "missing url",
+			opts:  []Option{WithCredentials("user", "pass")},
+			class: ErrConfig,
+		},
+		{
+			name:  "malformed url",
+			opts:  []Option{WithURL("http://[::1"), WithCredentials("user", "pass")},
+			class: ErrConfig,
+		},
+		{
+			name:  "missing CA bundle",
+			opts:  []Option{WithURL(s.URL.String()), WithCAPath("/does/not/exist"), WithCredentials("user", "pass")},
+			class: ErrConfig,
+		},
+		{
+			name:  "missing secret",
+			opts:  []Option{WithURL(s.URL.String()), WithSecretPath("/does/not/exist")},
+			class: ErrCredentials,
+		},
+		{
+			name:  "invalid credentials",
+			opts:  []Option{WithURL(s.URL.String()), WithThumbprint(thumbprint), WithCredentials("user", "")},
+			class: ErrAuthentication,
+		},
+		{
+			name:  "untrusted certificate",
+			opts:  []Option{WithURL(s.URL.String()), WithCredentials("user", "pass")},
+			class: ErrTLS,
+		},
+		{
+			name:  "wrong thumbprint",
+			opts:  []Option{WithURL(s.URL.String()), WithThumbprint(wrongThumbprint), WithCredentials("user", "pass")},
+			class: ErrTLS,
+		},
+		{
+			name:  "unreachable",
+			opts:  []Option{WithURL("https://127.0.0.1:1/sdk"), WithCredentials("user", "pass"), WithDialTimeout(time.Second)},
+			class: ErrUnreachable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(context.Background(), tc.opts...)
+			assert.ErrorIs(t, err, tc.class)
+
+			var vErr *Error
+			assert.Assert(t, errors.As(err, &vErr))
+			assert.Equal(t, vErr.Class, tc.class)
+			assert.Assert(t, vErr.Op != "")
+		})
+	}
+}
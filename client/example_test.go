@@ -30,8 +30,8 @@ func ExampleNew() {
 		}
 
 		defer func() {
-			if err = c.Logout(); err != nil {
-				l.Warn("logout", zap.Error(err))
+			if err = c.Close(ctx); err != nil {
+				l.Warn("close", zap.Error(err))
 			}
 		}()
 
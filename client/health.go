@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/vmware/govmomi/vim25/methods"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// Status is the result of a health check of the vCenter API sessions
+type Status struct {
+	// Healthy is true if the SOAP and, if enabled, REST sessions are active
+	Healthy bool
+	SOAP    SessionStatus
+	// REST is nil if the REST API is not enabled
+	REST *SessionStatus
+	// ClockSkew is the difference between the vCenter Server and the local
+	// clock, positive if the vCenter Server clock is ahead
+	ClockSkew time.Duration
+}
+
+// SessionStatus is the health status of a vCenter API session
+type SessionStatus struct {
+	Active bool
+	// User is the user of the active session
+	User string
+	// Latency is the duration of the session check request
+	Latency time.Duration
+	// Error is the reason why the session is not active
+	Error error
+}
+
+type sessionStatusJSON struct {
+	Active  bool   `json:"active"`
+	User    string `json:"user,omitempty"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (s SessionStatus) MarshalJSON() ([]byte, error) {
+	j := sessionStatusJSON{
+		Active:  s.Active,
+		User:    s.User,
+		Latency: s.Latency.String(),
+	}
+	if s.Error != nil {
+		j.Error = s.Error.Error()
+	}
+	return json.Marshal(j)
+}
+
+// MarshalJSON implements json.Marshaler
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Healthy   bool           `json:"healthy"`
+		SOAP      SessionStatus  `json:"soap"`
+		REST      *SessionStatus `json:"rest,omitempty"`
+		ClockSkew string         `json:"clockSkew"`
+	}{
+		Healthy:   s.Healthy,
+		SOAP:      s.SOAP,
+		REST:      s.REST,
+		ClockSkew: s.ClockSkew.String(),
+	})
+}
+
+// Check verifies that the SOAP and REST sessions are active and returns the
+// health status. An error is returned if any of the sessions is not active.
+func (c *Client) Check(ctx context.Context) (Status, error) {
+	var (
+		status Status
+		result error
+	)
+
+	status.SOAP, status.ClockSkew = c.checkSOAP(ctx)
+	if status.SOAP.Error != nil {
+		result = multierror.Append(result, fmt.Errorf("SOAP session: %w", status.SOAP.Error))
+	}
+
+	if c.HasREST() {
+		rest := c.checkREST(ctx)
+		if rest.Error != nil {
+			result = multierror.Append(result, fmt.Errorf("REST session: %w", rest.Error))
+		}
+		status.REST = &rest
+	}
+
+	status.Healthy = result == nil
+	return status, result
+}
+
+// checkSOAP checks the SOAP session and estimates the clock skew
+func (c *Client) checkSOAP(ctx context.Context) (SessionStatus, time.Duration) {
+	var status SessionStatus
+
+	start := time.Now()
+	s, err := c.SOAP.SessionManager.UserSession(ctx)
+	status.Latency = time.Since(start)
+
+	switch {
+	case err != nil:
+		status.Error = err
+		return status, 0
+	case s == nil:
+		status.Error = errors.New("not authenticated")
+		return status, 0
+	}
+
+	status.Active = true
+	status.User = s.UserName
+
+	start = time.Now()
+	now, err := methods.GetCurrentTime(ctx, c.SOAP)
+	if err != nil {
+		// the session is active, i.e. a skew is not worth failing the check
+		logger.Get(ctx).Warn("retrieve vCenter current time", zap.Error(err))
+		return status, 0
+	}
+	rtt := time.Since(start)
+
+	// assume the server time was taken halfway through the request
+	return status, now.Sub(start.Add(rtt / 2))
+}
+
+// checkREST checks the REST session
+func (c *Client) checkREST(ctx context.Context) SessionStatus {
+	var status SessionStatus
+
+	start := time.Now()
+	s, err := c.restSession(ctx)
+	status.Latency = time.Since(start)
+
+	switch {
+	case err != nil:
+		status.Error = err
+	case s == nil:
+		status.Error = errors.New("not authenticated")
+	default:
+		status.Active = true
+		status.User = s.User
+	}
+
+	return status
+}
+
+// HealthHandler returns a http.Handler which runs Check and responds with the
+// status as JSON. The status code is 200 if the client is healthy and 503
+// otherwise. The handler can be mounted on liveness and readiness endpoints,
+// e.g. /healthz and /readyz.
+func (c *Client) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, err := c.Check(r.Context())
+
+		code := http.StatusOK
+		if err != nil {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err = json.NewEncoder(w).Encode(status); err != nil {
+			logger.Get(r.Context()).Error("encode health status", zap.Error(err))
+		}
+	})
+}
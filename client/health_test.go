@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+)
+
+func TestClient_Check(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+		)
+		assert.NilError(t, err)
+
+		t.Run("healthy", func(t *testing.T) {
+			status, err := c.Check(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, status.Healthy)
+			assert.Assert(t, status.SOAP.Active)
+			assert.Equal(t, status.SOAP.User, "user")
+			assert.Assert(t, status.SOAP.Latency > 0)
+			assert.Assert(t, status.REST.Active)
+			assert.Equal(t, status.REST.User, "user")
+
+			rec := httptest.NewRecorder()
+			c.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, rec.Code, http.StatusOK)
+			assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+
+			var body map[string]interface{}
+			assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, body["healthy"], true)
+		})
+
+		t.Run("unhealthy REST session", func(t *testing.T) {
+			// logout the session with another client to not stop the keep-alive
+			rc := rest.NewClient(vimclient)
+			rc.SessionID(c.REST.SessionID())
+			assert.NilError(t, rc.Logout(ctx))
+
+			status, err := c.Check(ctx)
+			assert.ErrorContains(t, err, "REST session")
+			assert.Assert(t, !status.Healthy)
+			assert.Assert(t, status.SOAP.Active)
+			assert.Assert(t, !status.REST.Active)
+
+			rec := httptest.NewRecorder()
+			c.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, rec.Code, http.StatusServiceUnavailable)
+
+			var body struct {
+				Healthy bool `json:"healthy"`
+				REST    struct {
+					Active bool   `json:"active"`
+					Error  string `json:"error"`
+				} `json:"rest"`
+			}
+			assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Assert(t, !body.Healthy)
+			assert.Assert(t, !body.REST.Active)
+			assert.Equal(t, body.REST.Error, "not authenticated")
+		})
+
+		// terminated sessions are not considered a failure
+		assert.NilError(t, c.Close(ctx))
+		return nil
+	})
+}
+
+func TestClient_CheckAfterClose(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		before := keepaliveGoroutines()
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, keepaliveGoroutines(), before+2)
+
+		assert.NilError(t, c.Close(ctx))
+		waitForKeepaliveGoroutines(t, before)
+
+		// checking the sessions must not restart the keep-alive handlers
+		status, err := c.Check(ctx)
+		assert.Assert(t, err != nil)
+		assert.Assert(t, !status.Healthy)
+		assert.Assert(t, !c.sessionActive(ctx, APIREST))
+		assert.Equal(t, keepaliveGoroutines(), before)
+
+		return nil
+	})
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Info describes the vCenter Server or ESXi endpoint of a client
+type Info struct {
+	// Version is the parsed product version, e.g. 8.0.2
+	Version Version
+	// Build is the product build number, e.g. "22617221"
+	Build string
+	// InstanceUUID is the unique ID of the vCenter Server instance (empty for
+	// ESXi hosts)
+	InstanceUUID string
+	// APIType is "VirtualCenter" for vCenter Server or "HostAgent" for ESXi
+	APIType string
+	// ProductLine is the product line ID, e.g. "vpx" or "embeddedEsx"
+	ProductLine string
+	// FullName is the complete product name including version and build
+	FullName string
+}
+
+// IsVC returns true if the endpoint is a vCenter Server
+func (i Info) IsVC() bool {
+	return i.APIType == "VirtualCenter"
+}
+
+// Version is a product version consisting of major, minor and patch
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses a version in the format "major[.minor[.patch]]".
+// Additional components, e.g. "8.0.2.0", are ignored.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	if s == "" {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+
+	parts := strings.Split(s, ".")
+	dst := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i := 0; i < len(parts) && i < len(dst); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*dst[i] = n
+	}
+
+	return v, nil
+}
+
+// String returns the version as "major.minor.patch"
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or greater than o
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast returns true if v is equal to or greater than o
+func (v Version) AtLeast(o Version) bool {
+	return v.Compare(o) >= 0
+}
+
+// newInfo returns the Info for the given about information
+func newInfo(about types.AboutInfo) (Info, error) {
+	info := Info{
+		Build:        about.Build,
+		InstanceUUID: about.InstanceUuid,
+		APIType:      about.ApiType,
+		ProductLine:  about.ProductLineId,
+		FullName:     about.FullName,
+	}
+
+	v, err := ParseVersion(about.Version)
+	if err != nil {
+		return info, err
+	}
+	info.Version = v
+
+	return info, nil
+}
+
+// Info returns information about the vCenter Server or ESXi endpoint
+// retrieved at login
+func (c *Client) Info() Info {
+	return c.info
+}
+
+// SupportsAtLeast returns true if the endpoint version is equal to or greater
+// than the given version, e.g. "7.0.3". Returns false if version is invalid.
+func (c *Client) SupportsAtLeast(version string) bool {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	return c.info.Version.AtLeast(v)
+}
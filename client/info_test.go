@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		version string
+		want    Version
+		wantErr bool
+	}{
+		{version: "8.0.2.0", want: Version{Major: 8, Minor: 0, Patch: 2}},
+		{version: "7.0.3", want: Version{Major: 7, Minor: 0, Patch: 3}},
+		{version: "7.0", want: Version{Major: 7}},
+		{version: "7", want: Version{Major: 7}},
+		{version: "", wantErr: true},
+		{version: "7.x", wantErr: true},
+		{version: "-1.0", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			v, err := ParseVersion(tc.version)
+			if tc.wantErr {
+				assert.ErrorContains(t, err, "invalid version")
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, v, tc.want)
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	v := Version{Major: 7, Minor: 0, Patch: 3}
+
+	assert.Equal(t, v.Compare(Version{Major: 7, Minor: 0, Patch: 3}), 0)
+	assert.Equal(t, v.Compare(Version{Major: 7, Minor: 0, Patch: 2}), 1)
+	assert.Equal(t, v.Compare(Version{Major: 6, Minor: 7, Patch: 9}), 1)
+	assert.Equal(t, v.Compare(Version{Major: 7, Minor: 1}), -1)
+	assert.Equal(t, v.Compare(Version{Major: 8}), -1)
+	assert.Equal(t, v.String(), "7.0.3")
+}
+
+func TestClient_Info(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+		)
+		assert.NilError(t, err)
+
+		about := vimclient.ServiceContent.About
+		info := c.Info()
+		assert.Equal(t, info.Version.String(), about.Version)
+		assert.Equal(t, info.Build, about.Build)
+		assert.Equal(t, info.InstanceUUID, about.InstanceUuid)
+		assert.Equal(t, info.APIType, "VirtualCenter")
+		assert.Equal(t, info.ProductLine, "vpx")
+		assert.Assert(t, info.IsVC())
+
+		// the simulator reports version 6.5.0
+		assert.Assert(t, c.SupportsAtLeast("6.0"))
+		assert.Assert(t, c.SupportsAtLeast("6.5.0"))
+		assert.Assert(t, !c.SupportsAtLeast("6.7"))
+		assert.Assert(t, !c.SupportsAtLeast("invalid"))
+
+		assert.NilError(t, c.Close(ctx))
+		return nil
+	})
+}
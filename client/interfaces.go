@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// TagReader reads tags, categories and tag associations. It is implemented by
+// *tags.Manager, i.e. Client.Tags, and can be replaced with a fake in unit
+// tests.
+type TagReader interface {
+	GetTag(ctx context.Context, id string) (*tags.Tag, error)
+	GetTags(ctx context.Context) ([]tags.Tag, error)
+	GetCategory(ctx context.Context, id string) (*tags.Category, error)
+	GetCategories(ctx context.Context) ([]tags.Category, error)
+	GetAttachedTags(ctx context.Context, ref mo.Reference) ([]tags.Tag, error)
+	ListAttachedObjects(ctx context.Context, tagID string) ([]mo.Reference, error)
+}
+
+// TaskWaiter waits for the completion of a vCenter task. It is implemented by
+// *object.Task and can be replaced with a fake in unit tests.
+type TaskWaiter interface {
+	Wait(ctx context.Context) error
+	WaitForResult(ctx context.Context, s ...progress.Sinker) (*types.TaskInfo, error)
+}
+
+var (
+	_ TagReader  = (*tags.Manager)(nil)
+	_ TaskWaiter = (*object.Task)(nil)
+)
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	limiterRate        = "rate"
+	limiterConcurrency = "concurrency"
+)
+
+// limiter limits the rate and number of in-flight SOAP and REST requests of a
+// client. Waiting requests are served in order.
+type limiter struct {
+	// rate is nil if the rate is not limited
+	rate *rate.Limiter
+	// slots is nil if the number of in-flight requests is not limited
+	slots   chan struct{}
+	metrics *metrics
+}
+
+// newLimiter returns a limiter for the given configuration or nil if neither
+// the rate nor the number of in-flight requests is limited
+func newLimiter(cfg Config, m *metrics) *limiter {
+	if cfg.RateLimit <= 0 && cfg.MaxInFlight <= 0 {
+		return nil
+	}
+
+	l := limiter{metrics: m}
+	if cfg.RateLimit > 0 {
+		burst := cfg.RateBurst
+		if burst <= 0 {
+			burst = 1
+		}
+		l.rate = rate.NewLimiter(rate.Limit(cfg.RateLimit), burst)
+	}
+	if cfg.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, cfg.MaxInFlight)
+	}
+
+	return &l
+}
+
+// acquire blocks until the request is allowed by the concurrency and rate
+// limits or ctx is done. The returned function must be called when the request
+// completes.
+func (l *limiter) acquire(ctx context.Context, api API) (func(), error) {
+	release := func() {}
+
+	if l.slots != nil {
+		start := time.Now()
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		l.metrics.observeWait(api, limiterConcurrency, time.Since(start))
+		l.metrics.addInFlight(api, 1)
+
+		release = func() {
+			l.metrics.addInFlight(api, -1)
+			<-l.slots
+		}
+	}
+
+	if l.rate != nil {
+		start := time.Now()
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+		l.metrics.observeWait(api, limiterRate, time.Since(start))
+	}
+
+	return release, nil
+}
+
+// limitSOAP returns a soap.RoundTripper which waits for the limiter before
+// each SOAP request
+func limitSOAP(rt soap.RoundTripper, l *limiter) soap.RoundTripper {
+	return soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		release, err := l.acquire(ctx, APISOAP)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return rt.RoundTrip(ctx, req, res)
+	})
+}
+
+// limitREST returns a http.RoundTripper which waits for the limiter before
+// each REST request. The in-flight slot is released when the response is
+// received, i.e. the body is not accounted for.
+func limitREST(rt http.RoundTripper, l *limiter) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		release, err := l.acquire(req.Context(), APIREST)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return rt.RoundTrip(req)
+	})
+}
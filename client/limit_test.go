@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func Test_newLimiter(t *testing.T) {
+	assert.Assert(t, newLimiter(Config{}, nil) == nil)
+
+	l := newLimiter(Config{RateLimit: 10}, nil)
+	assert.Equal(t, l.rate.Burst(), 1)
+	assert.Assert(t, l.slots == nil)
+
+	l = newLimiter(Config{MaxInFlight: 2}, nil)
+	assert.Assert(t, l.rate == nil)
+	assert.Equal(t, cap(l.slots), 2)
+}
+
+func Test_limitSOAP(t *testing.T) {
+	t.Run("limits in-flight requests", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			inFlight int
+			peak     int
+		)
+
+		proceed := make(chan struct{})
+		rt := limitSOAP(soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+			mu.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			mu.Unlock()
+
+			<-proceed
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return nil
+		}), newLimiter(Config{MaxInFlight: 2}, nil))
+
+		waiting := goroutines(limiterAcquire)
+		errs := make(chan error, 10)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- rt.RoundTrip(context.Background(), &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{})
+			}()
+		}
+
+		// all requests without a slot wait for the limiter
+		waitForLimiterWaiters(t, waiting+8)
+		mu.Lock()
+		assert.Equal(t, inFlight, 2)
+		mu.Unlock()
+
+		close(proceed)
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NilError(t, err)
+		}
+
+		assert.Equal(t, peak, 2)
+	})
+
+	t.Run("serves waiting requests in order", func(t *testing.T) {
+		var (
+			mu    sync.Mutex
+			order []int
+		)
+
+		l := newLimiter(Config{MaxInFlight: 1}, nil)
+		release, err := l.acquire(context.Background(), APISOAP)
+		assert.NilError(t, err)
+
+		waiting := goroutines(limiterAcquire)
+		errs := make(chan error, 5)
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				r, err := l.acquire(context.Background(), APISOAP)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				r()
+			}(i)
+			// enqueue the waiters one after another
+			waitForLimiterWaiters(t, waiting+i+1)
+		}
+
+		release()
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NilError(t, err)
+		}
+		assert.DeepEqual(t, order, []int{0, 1, 2, 3, 4})
+	})
+
+	t.Run("limits request rate", func(t *testing.T) {
+		rt := limitSOAP(soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+			return nil
+		}), newLimiter(Config{RateLimit: 50, RateBurst: 1}, nil))
+
+		start := time.Now()
+		for i := 0; i < 6; i++ {
+			assert.NilError(t, rt.RoundTrip(context.Background(), &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{}))
+		}
+		assert.Assert(t, time.Since(start) >= 90*time.Millisecond)
+	})
+
+	t.Run("returns when context is done", func(t *testing.T) {
+		l := newLimiter(Config{MaxInFlight: 1, RateLimit: 0.001, RateBurst: 1}, nil)
+		rt := limitSOAP(soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+			return nil
+		}), l)
+
+		// uses the only slot and token
+		assert.NilError(t, rt.RoundTrip(context.Background(), &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := rt.RoundTrip(ctx, &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{})
+		assert.Assert(t, err != nil)
+
+		// the slot is released when waiting for the rate limiter fails
+		assert.Equal(t, len(l.slots), 0)
+
+		held := newLimiter(Config{MaxInFlight: 1}, nil)
+		_, err = held.acquire(context.Background(), APISOAP)
+		assert.NilError(t, err)
+
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = held.acquire(canceled, APISOAP)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestNewClientLimits(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		reg := prometheus.NewRegistry()
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithRateLimit(100, 10),
+			WithMaxInFlight(2),
+			WithMetrics(reg),
+		)
+		assert.NilError(t, err)
+
+		errs := make(chan error, 10)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.Finder.VirtualMachineList(ctx, "/DC0/vm/*")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NilError(t, err)
+		}
+
+		_, err = c.Tags.GetTags(ctx)
+		assert.NilError(t, err)
+
+		n, err := testutil.GatherAndCount(reg, "vsphere_client_limiter_wait_seconds")
+		assert.NilError(t, err)
+		// rate and concurrency limiter for SOAP and REST
+		assert.Equal(t, n, 4)
+
+		assert.Equal(t, testutil.ToFloat64(c.metrics.inFlight.WithLabelValues(string(APISOAP))), 0.0)
+
+		assert.NilError(t, c.Close(ctx))
+		return nil
+	})
+}
+
+// limiterAcquire identifies goroutines waiting for a limiter in their stack
+const limiterAcquire = "client.(*limiter).acquire("
+
+// waitForLimiterWaiters waits until n goroutines wait for a limiter
+func waitForLimiterWaiters(t *testing.T, n int) {
+	t.Helper()
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if got := goroutines(limiterAcquire); got != n {
+			return poll.Continue("waiting for %d limiter waiters, got %d", n, got)
+		}
+		return poll.Success()
+	}, poll.WithDelay(time.Millisecond), poll.WithTimeout(5*time.Second))
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// soapRoundTripperFunc is an adapter to allow the use of ordinary functions as
+// soap.RoundTripper
+type soapRoundTripperFunc func(ctx context.Context, req, res soap.HasFault) error
+
+// RoundTrip implements soap.RoundTripper
+func (f soapRoundTripperFunc) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	return f(ctx, req, res)
+}
+
+// roundTripperFunc is an adapter to allow the use of ordinary functions as
+// http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// soapMethod returns the SOAP method name of the given request body, e.g.
+// RetrieveProperties for *methods.RetrievePropertiesBody
+func soapMethod(req soap.HasFault) string {
+	t := reflect.TypeOf(req)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.TrimSuffix(t.Name(), "Body")
+}
+
+// readMethodPrefixes are SOAP method prefixes which do not modify the
+// inventory
+var readMethodPrefixes = []string{
+	"Retrieve",
+	"Read",
+	"Query",
+	"Find",
+	"Fetch",
+	"Has",
+}
+
+// readMethods are SOAP methods which do not modify the inventory and do not
+// match readMethodPrefixes
+var readMethods = map[string]bool{
+	"CurrentTime":       true,
+	"SessionIsActive":   true,
+	"WaitForUpdates":    true,
+	"WaitForUpdatesEx":  true,
+	"CheckForUpdates":   true,
+	"ValidateMigration": true,
+
+	"GetAlarm":                         true,
+	"GetAlarmState":                    true,
+	"GetClusterMode":                   true,
+	"GetCryptoKeyStatus":               true,
+	"GetCustomizationSpec":             true,
+	"GetDefaultKmsCluster":             true,
+	"GetPublicKey":                     true,
+	"GetResourceUsage":                 true,
+	"GetSiteInfo":                      true,
+	"GetSystemVMsRestrictedDatastores": true,
+	"GetVchaClusterHealth":             true,
+	"GetVchaConfig":                    true,
+	"GetVsanObjExtAttrs":               true,
+}
+
+// isReadMethod returns true if the SOAP method does not modify the inventory
+// and is safe to retry
+func isReadMethod(method string) bool {
+	if readMethods[method] {
+		return true
+	}
+
+	for _, p := range readMethodPrefixes {
+		if strings.HasPrefix(method, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isReadRequest returns true if the REST request does not modify the inventory
+// and is safe to retry. vAPI uses POST with an action query parameter for some
+// read operations, e.g. "~action=list-attached-tags".
+func isReadRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		action := req.URL.Query().Get("~action")
+		return strings.HasPrefix(action, "get") || strings.HasPrefix(action, "list")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"gotest.tools/v3/assert"
+)
+
+func Test_soapMethod(t *testing.T) {
+	assert.Equal(t, soapMethod(&methods.RetrievePropertiesBody{}), "RetrieveProperties")
+	assert.Equal(t, soapMethod(&methods.PowerOffVM_TaskBody{}), "PowerOffVM_Task")
+	assert.Equal(t, soapMethod(&methods.CurrentTimeBody{}), "CurrentTime")
+}
+
+func Test_isReadMethod(t *testing.T) {
+	for _, m := range []string{"RetrieveProperties", "ReadNextEvents", "QueryEvents", "FindByUuid", "CurrentTime", "WaitForUpdatesEx"} {
+		assert.Assert(t, isReadMethod(m), m)
+	}
+
+	for _, m := range []string{"PowerOffVM_Task", "Destroy_Task", "CreateCollectorForEvents", "Login", "SetCustomValue"} {
+		assert.Assert(t, !isReadMethod(m), m)
+	}
+}
+
+func Test_isReadRequest(t *testing.T) {
+	testCases := []struct {
+		method string
+		url    string
+		want   bool
+	}{
+		{method: http.MethodGet, url: "/rest/com/vmware/cis/tagging/tag", want: true},
+		{method: http.MethodPost, url: "/rest/com/vmware/cis/session?~action=get", want: true},
+		{method: http.MethodPost, url: "/rest/com/vmware/cis/tagging/tag-association?~action=list-attached-tags", want: true},
+		{method: http.MethodPost, url: "/rest/com/vmware/cis/tagging/tag-association/id:tag?~action=attach", want: false},
+		{method: http.MethodPost, url: "/rest/com/vmware/cis/session", want: false},
+		{method: http.MethodPatch, url: "/rest/com/vmware/cis/tagging/tag/id", want: false},
+		{method: http.MethodDelete, url: "/rest/com/vmware/cis/session", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "https://vcenter.local"+tc.url, strings.NewReader(""))
+			assert.NilError(t, err)
+			assert.Equal(t, isReadRequest(req), tc.want)
+		})
+	}
+}
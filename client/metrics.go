@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+const metricsNamespace = "vsphere_client"
+
+// metrics records vCenter API call metrics on a Prometheus registry. The
+// collectors are curried with the vCenter label of the client.
+type metrics struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	keepalive *prometheus.CounterVec
+	session   *prometheus.GaugeVec
+	wait      *prometheus.HistogramVec
+	inFlight  *prometheus.GaugeVec
+}
+
+// newMetrics creates the client metrics for the given vCenter and registers
+// them on the given registry. Metrics already registered, e.g. by another
+// client, are reused.
+func newMetrics(reg prometheus.Registerer, vcenter string) (*metrics, error) {
+	m := metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Total number of vCenter API requests by API, method and result code.",
+		}, []string{"vcenter", "api", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of vCenter API requests by API and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"vcenter", "api", "method"}),
+		keepalive: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "keepalive_total",
+			Help:      "Total number of session keep-alive requests by API and result.",
+		}, []string{"vcenter", "api", "result"}),
+		session: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "session_active",
+			Help:      "Whether the vCenter API session is active (1) or not (0).",
+		}, []string{"vcenter", "api"}),
+		wait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "limiter_wait_seconds",
+			Help:      "Time vCenter API requests waited for the rate or concurrency limiter by API and limiter.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"vcenter", "api", "limiter"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_in_flight",
+			Help:      "Number of vCenter API requests in flight by API if a concurrency limit is set.",
+		}, []string{"vcenter", "api"}),
+	}
+
+	c, err := register(reg, m.requests)
+	if err != nil {
+		return nil, err
+	}
+	m.requests = c.(*prometheus.CounterVec)
+
+	if c, err = register(reg, m.latency); err != nil {
+		return nil, err
+	}
+	m.latency = c.(*prometheus.HistogramVec)
+
+	if c, err = register(reg, m.keepalive); err != nil {
+		return nil, err
+	}
+	m.keepalive = c.(*prometheus.CounterVec)
+
+	if c, err = register(reg, m.session); err != nil {
+		return nil, err
+	}
+	m.session = c.(*prometheus.GaugeVec)
+
+	if c, err = register(reg, m.wait); err != nil {
+		return nil, err
+	}
+	m.wait = c.(*prometheus.HistogramVec)
+
+	if c, err = register(reg, m.inFlight); err != nil {
+		return nil, err
+	}
+	m.inFlight = c.(*prometheus.GaugeVec)
+
+	labels := prometheus.Labels{"vcenter": vcenter}
+	m.requests = m.requests.MustCurryWith(labels)
+	m.latency = m.latency.MustCurryWith(labels).(*prometheus.HistogramVec)
+	m.keepalive = m.keepalive.MustCurryWith(labels)
+	m.session = m.session.MustCurryWith(labels)
+	m.wait = m.wait.MustCurryWith(labels).(*prometheus.HistogramVec)
+	m.inFlight = m.inFlight.MustCurryWith(labels)
+
+	return &m, nil
+}
+
+// register registers c on reg and returns the already registered collector if
+// an equal collector exists
+func register(reg prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *metrics) observeRequest(api API, method, code string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(string(api), method, code).Inc()
+	m.latency.WithLabelValues(string(api), method).Observe(d.Seconds())
+}
+
+func (m *metrics) observeKeepalive(api API, err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.keepalive.WithLabelValues(string(api), result).Inc()
+}
+
+func (m *metrics) setSessionActive(api API, active bool) {
+	if m == nil {
+		return
+	}
+	v := 0.0
+	if active {
+		v = 1
+	}
+	m.session.WithLabelValues(string(api)).Set(v)
+}
+
+func (m *metrics) observeWait(api API, limiter string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.wait.WithLabelValues(string(api), limiter).Observe(d.Seconds())
+}
+
+func (m *metrics) addInFlight(api API, delta float64) {
+	if m == nil {
+		return
+	}
+	m.inFlight.WithLabelValues(string(api)).Add(delta)
+}
+
+// instrumentSOAP returns a soap.RoundTripper which records metrics by SOAP
+// method
+func instrumentSOAP(rt soap.RoundTripper, m *metrics) soap.RoundTripper {
+	return soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		start := time.Now()
+		err := rt.RoundTrip(ctx, req, res)
+		m.observeRequest(APISOAP, soapMethod(req), soapCode(err), time.Since(start))
+		return err
+	})
+}
+
+// instrumentREST returns a http.RoundTripper which records metrics by HTTP
+// method and path template
+func instrumentREST(rt http.RoundTripper, m *metrics) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		res, err := rt.RoundTrip(req)
+
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(res.StatusCode)
+		}
+		m.observeRequest(APIREST, req.Method+" "+pathTemplate(req.URL.Path), code, time.Since(start))
+		return res, err
+	})
+}
+
+// soapCode returns a low cardinality result code for the SOAP error, i.e. the
+// fault type name
+func soapCode(err error) string {
+	if err == nil {
+		return "OK"
+	}
+
+	if soap.IsSoapFault(err) {
+		f := soap.ToSoapFault(err)
+		if vf := f.VimFault(); vf != nil {
+			return typeName(vf)
+		}
+		return f.Code
+	}
+
+	if soap.IsVimFault(err) {
+		return typeName(soap.ToVimFault(err))
+	}
+
+	return "error"
+}
+
+func typeName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// idSegment matches path segments which are object identifiers, e.g. "vm-42",
+// "domain-c8", "id:urn:vmomi:InventoryServiceTag:...", UUIDs or numbers
+var idSegment = regexp.MustCompile(`^([a-z]+-[a-z]?\d+|.*:.*|[0-9a-fA-F-]{32,36}|\d+)$`)
+
+// pathTemplate replaces object identifiers in the REST path with "{id}" to
+// limit the metric cardinality
+func pathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if s != "" && idSegment.MatchString(s) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
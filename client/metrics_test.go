@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func TestClientMetrics(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		reg := prometheus.NewRegistry()
+		host := vimclient.URL().Host
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithKeepaliveInterval(50*time.Millisecond),
+			WithMetrics(reg),
+		)
+		assert.NilError(t, err)
+
+		_, err = methods.GetCurrentTime(ctx, c.SOAP)
+		assert.NilError(t, err)
+
+		_, err = c.Tags.GetTags(ctx)
+		assert.NilError(t, err)
+
+		m := c.metrics
+		assert.Equal(t, testutil.ToFloat64(m.requests.WithLabelValues(string(APISOAP), "CurrentTime", "OK")) >= 1, true)
+		assert.Equal(t, testutil.ToFloat64(m.requests.WithLabelValues(string(APIREST), "GET /rest/com/vmware/cis/tagging/tag", "200")), 1.0)
+		assert.Equal(t, testutil.ToFloat64(m.session.WithLabelValues(string(APISOAP))), 1.0)
+		assert.Equal(t, testutil.ToFloat64(m.session.WithLabelValues(string(APIREST))), 1.0)
+
+		poll.WaitOn(t, func(poll.LogT) poll.Result {
+			if testutil.ToFloat64(m.keepalive.WithLabelValues(string(APISOAP), "success")) == 0 ||
+				testutil.ToFloat64(m.keepalive.WithLabelValues(string(APIREST), "success")) == 0 {
+				return poll.Continue("waiting for keep-alive")
+			}
+			return poll.Success()
+		}, poll.WithTimeout(5*time.Second))
+
+		n, err := testutil.GatherAndCount(reg, "vsphere_client_request_duration_seconds")
+		assert.NilError(t, err)
+		assert.Assert(t, n > 0)
+
+		mfs, err := reg.Gather()
+		assert.NilError(t, err)
+		for _, mf := range mfs {
+			for _, metric := range mf.GetMetric() {
+				labels := make(map[string]string)
+				for _, l := range metric.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+				assert.Equal(t, labels["vcenter"], host, mf.GetName())
+			}
+		}
+
+		assert.NilError(t, c.Logout())
+		assert.Equal(t, testutil.ToFloat64(m.session.WithLabelValues(string(APISOAP))), 0.0)
+		return nil
+	})
+}
+
+func Test_newMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	m1, err := newMetrics(reg, "vc1.local")
+	assert.NilError(t, err)
+
+	// a second client shares the collectors on the same registry
+	m2, err := newMetrics(reg, "vc2.local")
+	assert.NilError(t, err)
+
+	m1.setSessionActive(APISOAP, true)
+	m2.setSessionActive(APISOAP, false)
+
+	n, err := testutil.GatherAndCount(reg, "vsphere_client_session_active")
+	assert.NilError(t, err)
+	assert.Equal(t, n, 2)
+
+	// nil metrics are a no-op
+	var m *metrics
+	m.observeKeepalive(APISOAP, errors.New("failed"))
+}
+
+func Test_pathTemplate(t *testing.T) {
+	testCases := map[string]string{
+		"/rest/com/vmware/cis/tagging/tag":                                                         "/rest/com/vmware/cis/tagging/tag",
+		"/rest/com/vmware/cis/tagging/tag/id:urn:vmomi:InventoryServiceTag:abc:GLOBAL":             "/rest/com/vmware/cis/tagging/tag/{id}",
+		"/rest/com/vmware/cis/tagging/tag-association":                                             "/rest/com/vmware/cis/tagging/tag-association",
+		"/api/vcenter/vm/vm-42/hardware":                                                           "/api/vcenter/vm/{id}/hardware",
+		"/api/content/library/item/8a4b7c2e-1f3d-4e5a-9b6c-7d8e9f0a1b2c":                           "/api/content/library/item/{id}",
+		"/api/vcenter/namespace-management/clusters/domain-c8/workload-networks/network-123/nodes": "/api/vcenter/namespace-management/clusters/{id}/workload-networks/{id}/nodes",
+	}
+
+	for path, want := range testCases {
+		assert.Equal(t, pathTemplate(path), want, path)
+	}
+}
+
+func Test_soapCode(t *testing.T) {
+	assert.Equal(t, soapCode(nil), "OK")
+	assert.Equal(t, soapCode(errors.New("connection reset")), "error")
+
+	notAuthenticated := soap.WrapSoapFault(&soap.Fault{
+		Code: "ServerFaultCode",
+		Detail: struct {
+			Fault types.AnyType `xml:",any,typeattr"`
+		}{Fault: &types.NotAuthenticated{}},
+	})
+	assert.Equal(t, soapCode(notAuthenticated), "NotAuthenticated")
+	assert.Equal(t, soapCode(soap.WrapSoapFault(&soap.Fault{Code: "ServerFaultCode"})), "ServerFaultCode")
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// operationIDHeader is the REST header carrying the operation ID (see
+// rest.Client.Do). The SOAP client sends the operation ID in the SOAP header.
+const operationIDHeader = "X-Request-ID"
+
+// WithOperationID returns a child context of ctx with the given vCenter
+// operation ID (opID) which is sent with all SOAP and REST requests made with
+// the context, e.g. to correlate a call with the vCenter logs. The logger in
+// the returned context includes the operation ID as "opID" field. If not set,
+// an operation ID is generated per request.
+func WithOperationID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, types.ID{}, id)
+	return logger.Set(ctx, logger.Get(ctx).With(zap.String("opID", id)))
+}
+
+// OperationID returns the operation ID of ctx or an empty string if not set
+func OperationID(ctx context.Context) string {
+	id, _ := ctx.Value(types.ID{}).(string)
+	return id
+}
+
+// newOperationID returns a random operation ID
+func newOperationID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "vsphere"
+	}
+	return hex.EncodeToString(b)
+}
+
+// operationIDSOAP returns a soap.RoundTripper which sets a generated operation
+// ID on the request context if not set
+func operationIDSOAP(rt soap.RoundTripper) soap.RoundTripper {
+	return soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		if OperationID(ctx) == "" {
+			ctx = WithOperationID(ctx, newOperationID())
+		}
+		return rt.RoundTrip(ctx, req, res)
+	})
+}
+
+// operationIDREST returns a http.RoundTripper which sets the operation ID
+// header and request context from the header, the request context or a
+// generated operation ID
+func operationIDREST(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+
+		id := req.Header.Get(operationIDHeader)
+		if id == "" {
+			id = OperationID(ctx)
+		}
+		if id == "" {
+			id = newOperationID()
+		}
+		if OperationID(ctx) != id {
+			ctx = WithOperationID(ctx, id)
+		}
+
+		// a round-tripper must not modify the request
+		req = req.Clone(ctx)
+		req.Header.Set(operationIDHeader, id)
+
+		return rt.RoundTrip(req)
+	})
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/v3/assert"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+func TestWithOperationID(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := logger.Set(context.Background(), zap.New(core))
+
+	assert.Equal(t, OperationID(ctx), "")
+
+	ctx = WithOperationID(ctx, "my-op")
+	assert.Equal(t, OperationID(ctx), "my-op")
+
+	logger.Get(ctx).Info("test")
+	assert.Equal(t, logs.All()[0].ContextMap()["opID"], "my-op")
+}
+
+func Test_operationIDSOAP(t *testing.T) {
+	var got string
+	rt := operationIDSOAP(soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		got = OperationID(ctx)
+		return nil
+	}))
+
+	assert.NilError(t, rt.RoundTrip(WithOperationID(context.Background(), "my-op"), &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{}))
+	assert.Equal(t, got, "my-op")
+
+	assert.NilError(t, rt.RoundTrip(context.Background(), &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{}))
+	generated := got
+	assert.Equal(t, len(generated), 12)
+
+	// generated per request
+	assert.NilError(t, rt.RoundTrip(context.Background(), &methods.CurrentTimeBody{}, &methods.CurrentTimeBody{}))
+	assert.Assert(t, got != generated)
+}
+
+func Test_operationIDREST(t *testing.T) {
+	var (
+		header string
+		ctxID  string
+	)
+	rt := operationIDREST(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header = req.Header.Get(operationIDHeader)
+		ctxID = OperationID(req.Context())
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	testCases := []struct {
+		name   string
+		ctx    context.Context
+		header string
+		want   string
+	}{
+		{name: "uses header", ctx: context.Background(), header: "from-header", want: "from-header"},
+		{name: "uses context", ctx: WithOperationID(context.Background(), "from-ctx"), want: "from-ctx"},
+		{name: "generates operation ID"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://vcenter.local/rest/com/vmware/cis/tagging/tag", nil)
+			assert.NilError(t, err)
+			if tc.header != "" {
+				req.Header.Set(operationIDHeader, tc.header)
+			}
+
+			_, err = rt.RoundTrip(req)
+			assert.NilError(t, err)
+
+			assert.Equal(t, header, ctxID)
+			if tc.want != "" {
+				assert.Equal(t, header, tc.want)
+			} else {
+				assert.Equal(t, len(header), 12)
+				// the original request is not modified
+				assert.Equal(t, req.Header.Get(operationIDHeader), "")
+			}
+		})
+	}
+}
+
+func TestNewClientOperationID(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		core, logs := observer.New(zapcore.ErrorLevel)
+		ctx = logger.Set(ctx, zap.New(core))
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithReadOnly(),
+		)
+		assert.NilError(t, err)
+
+		t.Run("sends operation ID in SOAP header", func(t *testing.T) {
+			var (
+				mu     sync.Mutex
+				bodies []string
+			)
+
+			httpClient := &c.SOAP.Client.Client.Client
+			transport := httpClient.Transport
+			httpClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				b, err := io.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				req.Body = io.NopCloser(bytes.NewReader(b))
+
+				mu.Lock()
+				bodies = append(bodies, string(b))
+				mu.Unlock()
+				return transport.RoundTrip(req)
+			})
+			defer func() {
+				httpClient.Transport = transport
+			}()
+
+			_, err := methods.GetCurrentTime(WithOperationID(ctx, "my-op"), c.SOAP)
+			assert.NilError(t, err)
+			_, err = methods.GetCurrentTime(ctx, c.SOAP)
+			assert.NilError(t, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, len(bodies), 2)
+			assert.Assert(t, strings.Contains(bodies[0], "<operationID>my-op</operationID>"), bodies[0])
+			assert.Assert(t, strings.Contains(bodies[1], "<operationID>"), bodies[1])
+		})
+
+		t.Run("adds operation ID to logs", func(t *testing.T) {
+			vm, err := c.Finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+			assert.NilError(t, err)
+
+			_, err = vm.PowerOff(WithOperationID(ctx, "my-op"))
+			assert.ErrorIs(t, err, ErrReadOnly)
+			_, err = vm.PowerOff(ctx)
+			assert.ErrorIs(t, err, ErrReadOnly)
+
+			entries := logs.FilterMessage("rejected SOAP method").All()
+			assert.Equal(t, len(entries), 2)
+			assert.Equal(t, entries[0].ContextMap()["opID"], "my-op")
+			assert.Equal(t, len(entries[1].ContextMap()["opID"].(string)), 12)
+		})
+
+		assert.NilError(t, c.Close(ctx))
+		return nil
+	})
+}
@@ -0,0 +1,423 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures a vsphere client
+type Option func(o *options) error
+
+// options holds the settings resolved from all Option values passed to a
+// constructor
+type options struct {
+	config Config
+
+	credentials CredentialProvider
+	tlsConfig   *tls.Config
+	cache       *sessionCache
+	retry       *RetryPolicy
+	registry    prometheus.Registerer
+	metrics     *metrics
+	tracer      trace.Tracer
+	audit       AuditSink
+	limiter     *limiter
+
+	// url is the parsed vCenter URL and host is the vCenter host used to label
+	// metrics, spans and audit records
+	url  *url.URL
+	host string
+	// transportTLS is the TLS configuration of the transport or nil for the
+	// defaults (see newTLSConfig)
+	transportTLS *tls.Config
+}
+
+// defaultOptions is used when no options are passed to a constructor and
+// preserves the environment based configuration
+var defaultOptions = []Option{WithEnv()}
+
+// newOptions applies the given options in order. If opts is empty, the client
+// is configured via environment variables.
+func newOptions(opts ...Option) (*options, error) {
+	if len(opts) == 0 {
+		opts = defaultOptions
+	}
+
+	o := options{
+		config: Config{
+			KeepaliveInterval: keepaliveInterval,
+		},
+	}
+
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.credentials == nil && o.config.SecretPath != "" {
+		o.credentials = FileCredentials(o.config.SecretPath)
+	}
+
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if o.url, err = soap.ParseURL(o.config.Address); err != nil {
+		// the URL might contain credentials
+		return nil, fmt.Errorf("parse vcenter URL: %w", redact(err))
+	}
+	o.host = o.url.Host
+
+	if o.transportTLS, err = newTLSConfig(&o); err != nil {
+		return nil, err
+	}
+
+	o.cache = newSessionCache(o.config.SessionCachePath)
+
+	if o.registry != nil {
+		if o.metrics, err = newMetrics(o.registry, o.host); err != nil {
+			return nil, fmt.Errorf("register metrics: %w", err)
+		}
+	}
+
+	o.limiter = newLimiter(o.config, o.metrics)
+
+	return &o, nil
+}
+
+func (o *options) validate() error {
+	if o.config.Address == "" {
+		return errors.New("vcenter URL must be specified")
+	}
+
+	if o.credentials == nil {
+		return errors.New("credentials or secret path must be specified")
+	}
+
+	if o.config.KeepaliveInterval <= 0 {
+		return errors.New("keep-alive interval must be greater than 0")
+	}
+
+	timeouts := map[string]time.Duration{
+		"dial":          o.config.DialTimeout,
+		"tls handshake": o.config.TLSHandshakeTimeout,
+		"response":      o.config.ResponseTimeout,
+		"login":         o.config.LoginTimeout,
+	}
+	for name, t := range timeouts {
+		if t < 0 {
+			return fmt.Errorf("%s timeout must not be negative", name)
+		}
+	}
+
+	if o.config.RateLimit < 0 || o.config.RateBurst < 0 || o.config.MaxInFlight < 0 {
+		return errors.New("rate limit, burst and max in-flight requests must not be negative")
+	}
+
+	if o.config.Insecure && (o.config.CAPath != "" || o.config.Thumbprint != "") {
+		return errors.New("insecure must not be combined with CA path or thumbprint")
+	}
+
+	if o.config.Thumbprint != "" {
+		if _, err := parseThumbprint(o.config.Thumbprint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WithEnv configures the client via environment variables (see Config). This
+// is the default if no options are specified. Options are applied in order,
+// i.e. options specified after WithEnv override the environment.
+func WithEnv() Option {
+	return func(o *options) error {
+		if err := envconfig.Process("", &o.config); err != nil {
+			return fmt.Errorf("process environment variables: %w", err)
+		}
+		return nil
+	}
+}
+
+// WithURL sets the vCenter Server URL
+func WithURL(url string) Option {
+	return func(o *options) error {
+		if url == "" {
+			return errors.New("url must not be empty")
+		}
+		o.config.Address = url
+		return nil
+	}
+}
+
+// WithInsecure configures whether vCenter Server certificate warnings are
+// ignored
+func WithInsecure(insecure bool) Option {
+	return func(o *options) error {
+		o.config.Insecure = insecure
+		return nil
+	}
+}
+
+// WithSecretPath sets the directory where the username and password files are
+// located to retrieve credentials
+func WithSecretPath(path string) Option {
+	return func(o *options) error {
+		if path == "" {
+			return errors.New("secret path must not be empty")
+		}
+		o.config.SecretPath = path
+		return nil
+	}
+}
+
+// WithCredentials sets the username and password used to authenticate against
+// vCenter Server. Takes precedence over the secret path.
+func WithCredentials(username, password string) Option {
+	return func(o *options) error {
+		if username == "" {
+			return errors.New("username must not be empty")
+		}
+		o.credentials = StaticCredentials(username, password)
+		return nil
+	}
+}
+
+// WithCredentialProvider sets the provider used to retrieve credentials to
+// authenticate against vCenter Server. Takes precedence over the secret path.
+func WithCredentialProvider(p CredentialProvider) Option {
+	return func(o *options) error {
+		if p == nil {
+			return errors.New("credential provider must not be nil")
+		}
+		o.credentials = p
+		return nil
+	}
+}
+
+// WithKeepaliveInterval sets the interval for the session keep-alive handlers
+func WithKeepaliveInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return errors.New("keep-alive interval must be greater than 0")
+		}
+		o.config.KeepaliveInterval = interval
+		return nil
+	}
+}
+
+// WithDialTimeout sets the maximum amount of time a dial to vCenter Server
+// waits for a connect to complete
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.config.DialTimeout = timeout
+		return nil
+	}
+}
+
+// WithTLSHandshakeTimeout sets the maximum amount of time to wait for a TLS
+// handshake with vCenter Server
+func WithTLSHandshakeTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.config.TLSHandshakeTimeout = timeout
+		return nil
+	}
+}
+
+// WithResponseTimeout sets the maximum amount of time to wait for the response
+// headers of a SOAP or REST request after writing the request
+func WithResponseTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.config.ResponseTimeout = timeout
+		return nil
+	}
+}
+
+// WithLoginTimeout sets the maximum amount of time for the client
+// construction, i.e. the retrieval of the credentials, the SOAP and REST
+// logins and lookups, e.g. of the default datacenter
+func WithLoginTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.config.LoginTimeout = timeout
+		return nil
+	}
+}
+
+// WithCAPath sets the path to a PEM encoded CA bundle used to verify the
+// vCenter Server certificate instead of the system trust store
+func WithCAPath(path string) Option {
+	return func(o *options) error {
+		if path == "" {
+			return errors.New("CA path must not be empty")
+		}
+		o.config.CAPath = path
+		return nil
+	}
+}
+
+// WithThumbprint pins the SHA-256 thumbprint of the vCenter Server
+// certificate, e.g. "AB:CD:...". If no CA path is specified, the pinned
+// certificate is trusted without verifying the certificate chain.
+func WithThumbprint(thumbprint string) Option {
+	return func(o *options) error {
+		if thumbprint == "" {
+			return errors.New("thumbprint must not be empty")
+		}
+		o.config.Thumbprint = thumbprint
+		return nil
+	}
+}
+
+// WithoutREST disables the REST (VAPI) API, i.e. only a SOAP session is
+// created. The REST API is always disabled for standalone ESXi hosts.
+func WithoutREST() Option {
+	return func(o *options) error {
+		o.config.DisableREST = true
+		return nil
+	}
+}
+
+// WithDatacenter sets the default datacenter of the finder (see Client.Finder)
+func WithDatacenter(name string) Option {
+	return func(o *options) error {
+		if name == "" {
+			return errors.New("datacenter must not be empty")
+		}
+		o.config.Datacenter = name
+		return nil
+	}
+}
+
+// WithReadOnly enables the read-only mode. SOAP methods and REST requests
+// which might modify the inventory, e.g. PowerOffVM_Task, are rejected with a
+// *ReadOnlyError. Read operations, e.g. Retrieve*, Read*, Query*, Find* or
+// GetAlarm, and the creation of sessions, collectors and views are allowed.
+func WithReadOnly() Option {
+	return func(o *options) error {
+		o.config.ReadOnly = true
+		return nil
+	}
+}
+
+// WithRateLimit limits the SOAP and REST requests of the client to rps
+// requests per second with bursts of up to burst requests. Requests wait for
+// the limiter in order and return early if their context is done. The limit
+// applies to the sum of SOAP and REST requests, including retries and
+// keep-alives.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *options) error {
+		if rps <= 0 {
+			return errors.New("rate limit must be greater than 0")
+		}
+		if burst <= 0 {
+			return errors.New("burst must be greater than 0")
+		}
+		o.config.RateLimit = rps
+		o.config.RateBurst = burst
+		return nil
+	}
+}
+
+// WithMaxInFlight limits the number of concurrent SOAP and REST requests of
+// the client. Requests wait for a free slot in order and return early if their
+// context is done.
+func WithMaxInFlight(n int) Option {
+	return func(o *options) error {
+		if n <= 0 {
+			return errors.New("max in-flight requests must be greater than 0")
+		}
+		o.config.MaxInFlight = n
+		return nil
+	}
+}
+
+// WithAudit sends an audit record to sink for each SOAP method and REST request
+// which might modify the inventory, e.g. PowerOffVM_Task or POST, PUT, PATCH
+// and DELETE requests except session logins and read actions. Labels can be
+// added to the records with ContextWithAuditLabels.
+func WithAudit(sink AuditSink) Option {
+	return func(o *options) error {
+		if sink == nil {
+			return errors.New("audit sink must not be nil")
+		}
+		o.audit = sink
+		return nil
+	}
+}
+
+// WithSessionCache enables the session cache. The SOAP session cookies and the
+// REST session ID are stored in the file at the given path with restrictive
+// permissions and reused on the next start if still valid.
+func WithSessionCache(path string) Option {
+	return func(o *options) error {
+		if path == "" {
+			return errors.New("session cache path must not be empty")
+		}
+		o.config.SessionCachePath = path
+		return nil
+	}
+}
+
+// WithRetry enables retries of read operations, e.g. RetrieveProperties or
+// ReadNextEvents, on transient errors such as connection resets, HTTP 503
+// during vCenter service restarts or busy faults. Retries use exponential
+// backoff with jitter. Operations which modify the inventory are never
+// retried.
+func WithRetry(p RetryPolicy) Option {
+	return func(o *options) error {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		o.retry = &p
+		return nil
+	}
+}
+
+// WithMetrics enables Prometheus metrics for SOAP and REST requests, session
+// keep-alives and the session state. The metrics are registered on the given
+// registry and labeled with the vCenter host, i.e. multiple clients can share
+// a registry.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(o *options) error {
+		if reg == nil {
+			return errors.New("metrics registry must not be nil")
+		}
+		o.registry = reg
+		return nil
+	}
+}
+
+// WithTracerProvider enables OpenTelemetry tracing. Each SOAP method and REST
+// request is recorded as a span with the vCenter host, method and fault code
+// as attributes. The parent span is taken from the context of the call.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) error {
+		if tp == nil {
+			return errors.New("tracer provider must not be nil")
+		}
+		o.tracer = tp.Tracer(tracerName)
+		return nil
+	}
+}
+
+// WithTLSConfig sets a custom TLS configuration for the SOAP and REST clients.
+// WithInsecure, if set, overrides InsecureSkipVerify.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *options) error {
+		if cfg == nil {
+			return errors.New("tls config must not be nil")
+		}
+		o.tlsConfig = cfg.Clone()
+		return nil
+	}
+}
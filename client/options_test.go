@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_newOptions(t *testing.T) {
+	t.Run("fails with invalid options", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			opts    []Option
+			wantErr string
+		}{
+			{
+				name:    "empty url",
+				opts:    []Option{WithURL("")},
+				wantErr: "url must not be empty",
+			},
+			{
+				name:    "url not specified",
+				opts:    []Option{WithCredentials("user", "pass")},
+				wantErr: "vcenter URL must be specified",
+			},
+			{
+				name:    "credentials not specified",
+				opts:    []Option{WithURL("https://vcenter.local")},
+				wantErr: "credentials or secret path must be specified",
+			},
+			{
+				name:    "empty username",
+				opts:    []Option{WithCredentials("", "pass")},
+				wantErr: "username must not be empty",
+			},
+			{
+				name:    "empty secret path",
+				opts:    []Option{WithSecretPath("")},
+				wantErr: "secret path must not be empty",
+			},
+			{
+				name:    "nil credential provider",
+				opts:    []Option{WithCredentialProvider(nil)},
+				wantErr: "credential provider must not be nil",
+			},
+			{
+				name:    "invalid keep-alive interval",
+				opts:    []Option{WithKeepaliveInterval(0)},
+				wantErr: "keep-alive interval must be greater than 0",
+			},
+			{
+				name:    "negative timeout",
+				opts:    []Option{WithURL("https://vcenter.local"), WithCredentials("user", "pass"), WithDialTimeout(-time.Second)},
+				wantErr: "dial timeout must not be negative",
+			},
+			{
+				name:    "invalid rate limit",
+				opts:    []Option{WithRateLimit(0, 1)},
+				wantErr: "rate limit must be greater than 0",
+			},
+			{
+				name:    "invalid burst",
+				opts:    []Option{WithRateLimit(10, 0)},
+				wantErr: "burst must be greater than 0",
+			},
+			{
+				name:    "invalid max in-flight requests",
+				opts:    []Option{WithMaxInFlight(0)},
+				wantErr: "max in-flight requests must be greater than 0",
+			},
+			{
+				name:    "malformed url",
+				opts:    []Option{WithURL("http://[::1"), WithCredentials("user", "pass")},
+				wantErr: "parse vcenter URL",
+			},
+			{
+				name:    "missing CA bundle",
+				opts:    []Option{WithURL("https://vcenter.local"), WithCredentials("user", "pass"), WithCAPath("/does/not/exist")},
+				wantErr: "read CA bundle",
+			},
+			{
+				name:    "nil tls config",
+				opts:    []Option{WithTLSConfig(nil)},
+				wantErr: "tls config must not be nil",
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := newOptions(tc.opts...)
+				assert.ErrorContains(t, err, tc.wantErr)
+			})
+		}
+	})
+
+	t.Run("uses environment by default", func(t *testing.T) {
+		t.Setenv("VCENTER_URL", "https://vcenter.local")
+		t.Setenv("VCENTER_INSECURE", "true")
+		t.Setenv("VCENTER_SECRET_PATH", "/tmp/secrets")
+
+		o, err := newOptions()
+		assert.NilError(t, err)
+		assert.Equal(t, o.config.Address, "https://vcenter.local")
+		assert.Equal(t, o.config.Insecure, true)
+		assert.Equal(t, o.config.SecretPath, "/tmp/secrets")
+		assert.Equal(t, o.config.KeepaliveInterval, keepaliveInterval)
+	})
+
+	t.Run("parses durations from environment", func(t *testing.T) {
+		t.Setenv("VCENTER_URL", "https://vcenter.local")
+		t.Setenv("VCENTER_KEEPALIVE_INTERVAL", "1m")
+		t.Setenv("VCENTER_DIAL_TIMEOUT", "5s")
+		t.Setenv("VCENTER_TLS_HANDSHAKE_TIMEOUT", "6s")
+		t.Setenv("VCENTER_RESPONSE_TIMEOUT", "7s")
+		t.Setenv("VCENTER_LOGIN_TIMEOUT", "8s")
+
+		o, err := newOptions()
+		assert.NilError(t, err)
+		assert.Equal(t, o.config.KeepaliveInterval, time.Minute)
+		assert.Equal(t, o.config.DialTimeout, 5*time.Second)
+		assert.Equal(t, o.config.TLSHandshakeTimeout, 6*time.Second)
+		assert.Equal(t, o.config.ResponseTimeout, 7*time.Second)
+		assert.Equal(t, o.config.LoginTimeout, 8*time.Second)
+
+		t.Setenv("VCENTER_KEEPALIVE_INTERVAL", "0s")
+		_, err = newOptions()
+		assert.ErrorContains(t, err, "keep-alive interval must be greater than 0")
+	})
+
+	t.Run("options override environment", func(t *testing.T) {
+		t.Setenv("VCENTER_URL", "https://vcenter.local")
+		t.Setenv("VCENTER_INSECURE", "true")
+
+		o, err := newOptions(
+			WithEnv(),
+			WithURL("https://other.local"),
+			WithInsecure(false),
+			WithCredentials("user", "pass"),
+			WithKeepaliveInterval(time.Minute),
+			WithTLSConfig(&tls.Config{ServerName: "vcenter"}),
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, o.config.Address, "https://other.local")
+		assert.Equal(t, o.config.Insecure, false)
+		creds, err := o.credentials.Credentials(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, creds.Username, "user")
+		assert.Equal(t, creds.Password, "pass")
+		assert.Equal(t, o.config.KeepaliveInterval, time.Minute)
+		assert.Equal(t, o.tlsConfig.ServerName, "vcenter")
+	})
+}
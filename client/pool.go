@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// ErrPoolClosed is returned when a closed Pool is used
+var ErrPoolClosed = errors.New("pool closed")
+
+// Endpoint is a named vCenter Server endpoint of a Pool
+type Endpoint struct {
+	// Name uniquely identifies the endpoint in the pool
+	Name string
+	// Options configure the client of the endpoint, e.g. WithURL and
+	// WithCredentialProvider. Must not be empty, i.e. the environment based
+	// configuration is not used.
+	Options []Option
+}
+
+// EndpointStatus is the health status of a Pool endpoint
+type EndpointStatus struct {
+	Name string
+	// Connected is true if a client for the endpoint was created
+	Connected bool
+	// Status is the result of Client.Check if connected
+	Status Status
+	// Error is the connect or check error
+	Error error
+}
+
+// Pool manages clients for multiple vCenter Server endpoints. Clients are
+// connected lazily on first use and independently of each other, i.e. an
+// unavailable endpoint does not affect the other endpoints.
+type Pool struct {
+	names []string // in configuration order
+
+	mu      sync.Mutex // guards closed
+	closed  bool
+	entries map[string]*poolEntry
+}
+
+type poolEntry struct {
+	endpoint Endpoint
+
+	mu         sync.Mutex // guards the fields below
+	client     *Client
+	lastErr    error         // last connect error
+	connecting chan struct{} // closed when the pending connect is done
+}
+
+// NewPool returns a Pool for the given endpoints. The endpoint options are
+// validated but no connection is established.
+//
+// Use Close() to log out from all connected endpoints.
+func NewPool(endpoints ...Endpoint) (*Pool, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("at least one endpoint must be specified")
+	}
+
+	p := Pool{
+		entries: make(map[string]*poolEntry, len(endpoints)),
+	}
+
+	for _, e := range endpoints {
+		if e.Name == "" {
+			return nil, errors.New("endpoint name must not be empty")
+		}
+
+		if _, ok := p.entries[e.Name]; ok {
+			return nil, fmt.Errorf("duplicate endpoint %q", e.Name)
+		}
+
+		if len(e.Options) == 0 {
+			return nil, fmt.Errorf("endpoint %q: options must be specified", e.Name)
+		}
+
+		if _, err := newOptions(e.Options...); err != nil {
+			return nil, fmt.Errorf("configure endpoint %q: %w", e.Name, err)
+		}
+
+		p.names = append(p.names, e.Name)
+		p.entries[e.Name] = &poolEntry{endpoint: e}
+	}
+
+	return &p, nil
+}
+
+// Names returns the endpoint names in configuration order
+func (p *Pool) Names() []string {
+	return append([]string(nil), p.names...)
+}
+
+// Get returns the client for the named endpoint and connects it if needed.
+// ctx bounds the connect (see New) and the wait for a pending connect of the
+// endpoint. If the connection fails, the next call attempts to connect again.
+func (p *Pool) Get(ctx context.Context, name string) (*Client, error) {
+	e, ok := p.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown endpoint %q", name)
+	}
+
+	e.mu.Lock()
+	for {
+		// checked while holding the entry lock to not race with Close
+		if p.isClosed() {
+			e.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		if e.client != nil {
+			c := e.client
+			e.mu.Unlock()
+			return c, nil
+		}
+
+		if e.connecting == nil {
+			break
+		}
+
+		// the entry is not locked while connecting, e.g. for Check
+		pending := e.connecting
+		e.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("connect endpoint %q: %w", name, ctx.Err())
+		case <-pending:
+		}
+		e.mu.Lock()
+	}
+
+	done := make(chan struct{})
+	e.connecting = done
+	e.mu.Unlock()
+
+	c, err := New(logger.Set(ctx, logger.Get(ctx).With(zap.String("endpoint", name))), e.endpoint.Options...)
+
+	e.mu.Lock()
+	e.connecting = nil
+	close(done)
+
+	if err != nil {
+		e.lastErr = err
+		e.mu.Unlock()
+		return nil, fmt.Errorf("connect endpoint %q: %w", name, err)
+	}
+
+	// the pool was closed while connecting
+	if p.isClosed() {
+		e.mu.Unlock()
+		if err = c.Close(ctx); err != nil {
+			logger.Get(ctx).Warn("close endpoint", zap.String("endpoint", name), zap.Error(err))
+		}
+		return nil, ErrPoolClosed
+	}
+
+	e.client = c
+	e.lastErr = nil
+	e.mu.Unlock()
+	return c, nil
+}
+
+// isClosed returns true if the pool is closed
+func (p *Pool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// Range calls f for each endpoint in configuration order and connects the
+// endpoints if needed (see Get). Endpoints which fail to connect are skipped and
+// their errors returned after all endpoints were visited. Range stops if f
+// returns an error.
+func (p *Pool) Range(ctx context.Context, f func(name string, c *Client) error) error {
+	var result error
+	for _, name := range p.names {
+		c, err := p.Get(ctx, name)
+		if err != nil {
+			if errors.Is(err, ErrPoolClosed) {
+				return err
+			}
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		if err = f(name, c); err != nil {
+			return err
+		}
+	}
+	return result
+}
+
+// Check returns the health status of all endpoints in configuration order
+// without connecting endpoints.
+func (p *Pool) Check(ctx context.Context) []EndpointStatus {
+	status := make([]EndpointStatus, 0, len(p.names))
+	for _, name := range p.names {
+		e := p.entries[name]
+
+		e.mu.Lock()
+		c, lastErr := e.client, e.lastErr
+		e.mu.Unlock()
+
+		s := EndpointStatus{Name: name, Error: lastErr}
+		if c != nil {
+			s.Connected = true
+			s.Status, s.Error = c.Check(ctx)
+		}
+		status = append(status, s)
+	}
+	return status
+}
+
+// Close closes the clients of all connected endpoints (see Client.Close). The
+// pool must not be used afterwards. If closing a client fails, e.g. the
+// deadline of ctx is exceeded, the client is closed again on the next call.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	var result error
+	for _, name := range p.names {
+		e := p.entries[name]
+
+		// pending connects close their client when done
+		e.mu.Lock()
+		c := e.client
+		e.mu.Unlock()
+
+		if c == nil {
+			continue
+		}
+
+		if err := c.Close(ctx); err != nil {
+			result = multierror.Append(result, fmt.Errorf("close endpoint %q: %w", name, err))
+			continue
+		}
+
+		e.mu.Lock()
+		if e.client == c {
+			e.client = nil
+		}
+		e.mu.Unlock()
+	}
+
+	return result
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func TestNewPool(t *testing.T) {
+	opts := []Option{WithURL("https://vcenter.local/sdk"), WithCredentials("user", "pass")}
+
+	testCases := []struct {
+		name      string
+		endpoints []Endpoint
+		wantErr   string
+	}{
+		{name: "no endpoints", wantErr: "at least one endpoint"},
+		{name: "empty name", endpoints: []Endpoint{{Options: opts}}, wantErr: "name must not be empty"},
+		{name: "duplicate name", endpoints: []Endpoint{{Name: "vc", Options: opts}, {Name: "vc", Options: opts}}, wantErr: `duplicate endpoint "vc"`},
+		{name: "no options", endpoints: []Endpoint{{Name: "vc"}}, wantErr: "options must be specified"},
+		{name: "invalid options", endpoints: []Endpoint{{Name: "vc", Options: []Option{WithCredentials("user", "pass")}}}, wantErr: `configure endpoint "vc"`},
+		{name: "valid", endpoints: []Endpoint{{Name: "vc1", Options: opts}, {Name: "vc2", Options: opts}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewPool(tc.endpoints...)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, p.Names(), []string{"vc1", "vc2"})
+		})
+	}
+}
+
+func TestPool(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		opts := []Option{
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+		}
+
+		p, err := NewPool(
+			Endpoint{Name: "vc1", Options: opts},
+			Endpoint{Name: "unreachable", Options: []Option{
+				WithURL("https://127.0.0.1:1/sdk"),
+				WithCredentials("user", "pass"),
+				WithDialTimeout(time.Second),
+			}},
+			Endpoint{Name: "vc2", Options: opts},
+		)
+		assert.NilError(t, err)
+
+		t.Run("connects lazily", func(t *testing.T) {
+			for _, s := range p.Check(ctx) {
+				assert.Assert(t, !s.Connected, s.Name)
+			}
+
+			c1, err := p.Get(ctx, "vc1")
+			assert.NilError(t, err)
+
+			c, err := p.Get(ctx, "vc1")
+			assert.NilError(t, err)
+			assert.Equal(t, c, c1)
+
+			_, err = p.Get(ctx, "unknown")
+			assert.ErrorContains(t, err, `unknown endpoint "unknown"`)
+		})
+
+		t.Run("iterates endpoints independently", func(t *testing.T) {
+			var visited []string
+			err := p.Range(ctx, func(name string, c *Client) error {
+				visited = append(visited, name)
+				return nil
+			})
+			assert.ErrorContains(t, err, `connect endpoint "unreachable"`)
+			assert.DeepEqual(t, visited, []string{"vc1", "vc2"})
+
+			stop := errors.New("stop")
+			err = p.Range(ctx, func(name string, c *Client) error {
+				return stop
+			})
+			assert.Equal(t, err, stop)
+		})
+
+		t.Run("tracks endpoint health", func(t *testing.T) {
+			status := p.Check(ctx)
+			assert.Equal(t, len(status), 3)
+
+			assert.Equal(t, status[0].Name, "vc1")
+			assert.Assert(t, status[0].Connected)
+			assert.Assert(t, status[0].Status.Healthy)
+			assert.NilError(t, status[0].Error)
+
+			assert.Equal(t, status[1].Name, "unreachable")
+			assert.Assert(t, !status[1].Connected)
+			assert.Assert(t, status[1].Error != nil)
+
+			assert.Equal(t, status[2].Name, "vc2")
+			assert.Assert(t, status[2].Status.Healthy)
+		})
+
+		t.Run("logs out on close", func(t *testing.T) {
+			c, err := p.Get(ctx, "vc1")
+			assert.NilError(t, err)
+
+			expired, cancel := context.WithCancel(ctx)
+			cancel()
+			assert.ErrorContains(t, p.Close(expired), `close endpoint "vc1"`)
+			assert.Assert(t, c.sessionActive(ctx, APISOAP))
+
+			// failed endpoints are closed again
+			assert.NilError(t, p.Close(ctx))
+			assert.Assert(t, !c.sessionActive(ctx, APISOAP))
+			for _, s := range p.Check(ctx) {
+				assert.Assert(t, !s.Connected, s.Name)
+			}
+			assert.NilError(t, p.Close(ctx))
+
+			_, err = p.Get(ctx, "vc1")
+			assert.ErrorIs(t, err, ErrPoolClosed)
+		})
+
+		return nil
+	})
+}
+
+func TestPool_Get(t *testing.T) {
+	// never responds within the test
+	done := make(chan struct{})
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer func() {
+		close(done)
+		s.Close()
+	}()
+
+	p, err := NewPool(Endpoint{Name: "hanging", Options: []Option{
+		WithURL(s.URL),
+		WithInsecure(true),
+		WithCredentials("user", "pass"),
+	}})
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	connectErr := make(chan error, 1)
+	go func() {
+		_, err := p.Get(ctx, "hanging")
+		connectErr <- err
+	}()
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		e := p.entries["hanging"]
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.connecting == nil {
+			return poll.Continue("waiting for connect")
+		}
+		return poll.Success()
+	})
+
+	t.Run("checks without waiting for pending connect", func(t *testing.T) {
+		status := p.Check(context.Background())
+		assert.Equal(t, len(status), 1)
+		assert.Assert(t, !status[0].Connected)
+	})
+
+	t.Run("returns when waiting for pending connect is cancelled", func(t *testing.T) {
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer waitCancel()
+
+		_, err := p.Get(waitCtx, "hanging")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("returns when connect is cancelled", func(t *testing.T) {
+		cancel()
+		select {
+		case err := <-connectErr:
+			assert.ErrorContains(t, err, `connect endpoint "hanging"`)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for connect")
+		}
+
+		status := p.Check(context.Background())
+		assert.Assert(t, status[0].Error != nil)
+	})
+
+	assert.NilError(t, p.Close(context.Background()))
+}
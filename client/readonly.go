@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// ErrReadOnly is matched by errors.Is for requests rejected in read-only mode
+var ErrReadOnly = errors.New("rejected in read-only mode")
+
+// ReadOnlyError is returned for SOAP methods and REST requests which are
+// rejected in read-only mode (see WithReadOnly)
+type ReadOnlyError struct {
+	API API
+	// Method is the SOAP method, e.g. PowerOffVM_Task, or the REST method and
+	// path, e.g. "POST /rest/com/vmware/cis/tagging/tag"
+	Method string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s %s %s", e.API, e.Method, ErrReadOnly)
+}
+
+// Is returns true if target is ErrReadOnly
+func (e *ReadOnlyError) Is(target error) bool {
+	return target == ErrReadOnly
+}
+
+// readOnlySessionMethods are SOAP methods which do not modify the inventory and
+// are needed to manage sessions, collectors and views
+var readOnlySessionMethods = map[string]bool{
+	"Login":                       true,
+	"LoginByToken":                true,
+	"LoginExtensionByCertificate": true,
+	"Logout":                      true,
+	"CreateCollectorForEvents":    true,
+	"CreateCollectorForTasks":     true,
+	"SetCollectorPageSize":        true,
+	"ResetCollector":              true,
+	"RewindCollector":             true,
+	"DestroyCollector":            true,
+	"CreatePropertyCollector":     true,
+	"DestroyPropertyCollector":    true,
+	"CreateFilter":                true,
+	"DestroyPropertyFilter":       true,
+	"CancelWaitForUpdates":        true,
+	"CreateContainerView":         true,
+	"CreateListView":              true,
+	"DestroyView":                 true,
+}
+
+// isReadOnlyMethod returns true if the SOAP method is allowed in read-only mode
+func isReadOnlyMethod(method string) bool {
+	return isReadMethod(method) || readOnlySessionMethods[method]
+}
+
+// isReadOnlyRequest returns true if the REST request is allowed in read-only
+// mode, i.e. a read request or a session login or logout
+func isReadOnlyRequest(req *http.Request) bool {
+	if isReadRequest(req) {
+		return true
+	}
+
+	switch req.Method {
+	case http.MethodPost, http.MethodDelete:
+		p := strings.TrimSuffix(req.URL.Path, "/")
+		return strings.HasSuffix(p, "/com/vmware/cis/session") || strings.HasSuffix(p, "/api/session")
+	default:
+		return false
+	}
+}
+
+// readOnlySOAP returns a soap.RoundTripper which rejects SOAP methods which
+// might modify the inventory
+func readOnlySOAP(rt soap.RoundTripper) soap.RoundTripper {
+	return soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		method := soapMethod(req)
+		if !isReadOnlyMethod(method) {
+			err := &ReadOnlyError{API: APISOAP, Method: method}
+			logger.Get(ctx).Error("rejected SOAP method", zap.String("method", method), zap.Error(err))
+			return err
+		}
+		return rt.RoundTrip(ctx, req, res)
+	})
+}
+
+// readOnlyREST returns a http.RoundTripper which rejects REST requests which
+// might modify the inventory
+func readOnlyREST(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !isReadOnlyRequest(req) {
+			method := req.Method + " " + req.URL.Path
+			err := &ReadOnlyError{API: APIREST, Method: method}
+			logger.Get(req.Context()).Error("rejected REST request", zap.String("method", method), zap.Error(err))
+			return nil, err
+		}
+		return rt.RoundTrip(req)
+	})
+}
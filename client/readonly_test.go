@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/v3/assert"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+func Test_isReadOnlyMethod(t *testing.T) {
+	for _, m := range []string{"RetrieveProperties", "ReadNextEvents", "QueryEvents", "CurrentTime", "Login", "Logout", "CreateCollectorForEvents", "DestroyCollector", "CreateContainerView", "GetAlarm", "GetAlarmState"} {
+		assert.Assert(t, isReadOnlyMethod(m), m)
+	}
+
+	for _, m := range []string{"PowerOffVM_Task", "Destroy_Task", "SetCustomValue", "CreateVM_Task", "AcknowledgeAlarm"} {
+		assert.Assert(t, !isReadOnlyMethod(m), m)
+	}
+}
+
+func Test_isReadOnlyRequest(t *testing.T) {
+	testCases := []struct {
+		method string
+		url    string
+		want   bool
+	}{
+		{method: http.MethodGet, url: "/rest/com/vmware/cis/tagging/tag", want: true},
+		{method: http.MethodPost, url: "/rest/com/vmware/cis/session", want: true},
+		{method: http.MethodDelete, url: "/rest/com/vmware/cis/session", want: true},
+		{method: http.MethodPost, url: "/api/session", want: true},
+		{method: http.MethodPost, url: "/rest/com/vmware/cis/tagging/tag", want: false},
+		{method: http.MethodPost, url: "/rest/com/vmware/cis/tagging/tag-association/id:tag?~action=attach", want: false},
+		{method: http.MethodDelete, url: "/rest/com/vmware/cis/tagging/tag/id", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "https://vcenter.local"+tc.url, strings.NewReader(""))
+			assert.NilError(t, err)
+			assert.Equal(t, isReadOnlyRequest(req), tc.want)
+		})
+	}
+}
+
+func TestNewClientReadOnly(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		core, logs := observer.New(zapcore.ErrorLevel)
+		ctx = logger.Set(ctx, zap.New(core))
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithReadOnly(),
+		)
+		assert.NilError(t, err)
+
+		t.Run("allows read operations", func(t *testing.T) {
+			vm, err := c.Finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+			assert.NilError(t, err)
+
+			state, err := vm.PowerState(ctx)
+			assert.NilError(t, err)
+			assert.Equal(t, state, types.VirtualMachinePowerStatePoweredOn)
+
+			collector, err := c.Events.CreateCollectorForEvents(ctx, types.EventFilterSpec{})
+			assert.NilError(t, err)
+			_, err = collector.ReadNextEvents(ctx, 10)
+			assert.NilError(t, err)
+			assert.NilError(t, collector.Destroy(ctx))
+
+			_, err = c.Tags.GetTags(ctx)
+			assert.NilError(t, err)
+		})
+
+		t.Run("allows read operations of all managers", func(t *testing.T) {
+			_, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			_, err = methods.GetCurrentTime(ctx, c.SOAP)
+			assert.NilError(t, err)
+
+			vm, err := c.Finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+			assert.NilError(t, err)
+			vmRef := vm.Reference()
+
+			_, err = c.REST.Session(ctx)
+			assert.NilError(t, err)
+			_, err = c.Tags.GetCategories(ctx)
+			assert.NilError(t, err)
+			_, err = c.Tags.ListAttachedTags(ctx, vmRef)
+			assert.NilError(t, err)
+
+			tasks, err := c.Tasks.CreateCollectorForTasks(ctx, types.TaskFilterSpec{})
+			assert.NilError(t, err)
+			_, err = tasks.LatestPage(ctx)
+			assert.NilError(t, err)
+			assert.NilError(t, tasks.Destroy(ctx))
+
+			_, err = c.Events.QueryEvents(ctx, types.EventFilterSpec{})
+			assert.NilError(t, err)
+
+			v, err := c.Views.CreateContainerView(ctx, c.SOAP.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+			assert.NilError(t, err)
+			var vms []mo.VirtualMachine
+			assert.NilError(t, v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"name"}, &vms))
+			assert.NilError(t, v.Destroy(ctx))
+
+			var props mo.VirtualMachine
+			assert.NilError(t, c.Properties.RetrieveOne(ctx, vmRef, []string{"runtime.powerState"}, &props))
+
+			_, err = c.CustomFields.Field(ctx)
+			assert.NilError(t, err)
+
+			// the simulator does not implement the alarm methods, i.e. the
+			// requests are not rejected if they reach the simulator
+			_, err = c.Alarms.GetAlarms(ctx, nil)
+			assert.Assert(t, !errors.Is(err, ErrReadOnly), err)
+			_, err = c.Alarms.GetAlarmState(ctx, vmRef)
+			assert.Assert(t, !errors.Is(err, ErrReadOnly), err)
+		})
+
+		t.Run("rejects SOAP methods which modify the inventory", func(t *testing.T) {
+			vm, err := c.Finder.VirtualMachine(ctx, "/DC0/vm/DC0_H0_VM0")
+			assert.NilError(t, err)
+
+			_, err = vm.PowerOff(ctx)
+			assert.ErrorIs(t, err, ErrReadOnly)
+
+			var roErr *ReadOnlyError
+			assert.Assert(t, errors.As(err, &roErr))
+			assert.Equal(t, roErr.API, APISOAP)
+			assert.Equal(t, roErr.Method, "PowerOffVM_Task")
+
+			state, err := vm.PowerState(ctx)
+			assert.NilError(t, err)
+			assert.Equal(t, state, types.VirtualMachinePowerStatePoweredOn)
+		})
+
+		t.Run("rejects REST requests which modify the inventory", func(t *testing.T) {
+			_, err := c.Tags.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "SINGLE"})
+			assert.ErrorIs(t, err, ErrReadOnly)
+		})
+
+		t.Run("logs rejected requests", func(t *testing.T) {
+			assert.Equal(t, logs.FilterMessage("rejected SOAP method").Len(), 1)
+			assert.Equal(t, logs.FilterMessage("rejected REST request").Len(), 1)
+		})
+
+		assert.NilError(t, c.Close(ctx))
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in error messages and log fields
+const redacted = "REDACTED"
+
+// redactedError hides secrets in the message of the wrapped error while
+// preserving the error chain for errors.Is and errors.As
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redact returns err with the password removed from the URL of a *url.Error
+// in the chain, e.g. returned by the HTTP client or url.Parse if the URL
+// contains credentials. Returns err if the URL does not contain a password.
+func redact(err error) error {
+	var uErr *url.Error
+	if !errors.As(err, &uErr) {
+		return err
+	}
+
+	redactedURL, ok := redactURL(uErr.URL)
+	if !ok {
+		return err
+	}
+
+	msg := strings.ReplaceAll(err.Error(), uErr.URL, redactedURL)
+	return &redactedError{err: err, msg: msg}
+}
+
+// redactURL returns raw with the password masked and true if raw contains a
+// password. The password of a malformed URL, e.g. with an invalid host, is
+// masked up to the last "@" following the scheme.
+func redactURL(raw string) (string, bool) {
+	if u, err := url.Parse(raw); err == nil {
+		if u.User == nil {
+			return raw, false
+		}
+		if _, ok := u.User.Password(); !ok {
+			return raw, false
+		}
+		return u.Redacted(), true
+	}
+
+	start := strings.Index(raw, "//") + 2
+	if start < 2 {
+		start = 0
+	}
+
+	at := strings.LastIndex(raw[start:], "@")
+	if at < 0 {
+		return raw, false
+	}
+
+	colon := strings.Index(raw[start:start+at], ":")
+	if colon < 0 {
+		return raw, false
+	}
+
+	return raw[:start+colon+1] + "xxxxx" + raw[start+at:], true
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// RetryPolicy configures retries of read operations on transient errors, e.g.
+// connection resets or HTTP 503 during vCenter service restarts
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first call
+	MaxAttempts int
+	// InitialBackoff is the backoff before the first retry which doubles with
+	// every retry
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper bound for the backoff
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is a RetryPolicy with sensible defaults
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 1 {
+		return errors.New("retry max attempts must be greater than 0")
+	}
+	if p.InitialBackoff <= 0 || p.MaxBackoff < p.InitialBackoff {
+		return errors.New("retry backoff must be greater than 0 and initial backoff must not exceed max backoff")
+	}
+	return nil
+}
+
+// backoff returns the backoff with equal jitter for the given retry (starting
+// at 1)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	b := p.InitialBackoff
+	for i := 1; i < retry && b < p.MaxBackoff; i++ {
+		b *= 2
+	}
+	if b > p.MaxBackoff {
+		b = p.MaxBackoff
+	}
+
+	half := b / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// wait blocks for the backoff of the given retry or until ctx is done
+func (p RetryPolicy) wait(ctx context.Context, retry int) error {
+	t := time.NewTimer(p.backoff(retry))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retrySOAP returns a soap.RoundTripper which retries read methods on
+// transient errors
+func retrySOAP(rt soap.RoundTripper, p RetryPolicy) soap.RoundTripper {
+	return soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		method := soapMethod(req)
+		if !isReadMethod(method) {
+			return rt.RoundTrip(ctx, req, res)
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := rt.RoundTrip(ctx, req, res)
+			if err == nil || attempt >= p.MaxAttempts || !isTransient(err) || ctx.Err() != nil {
+				return err
+			}
+
+			logger.Get(ctx).Debug("retrying SOAP method",
+				zap.String("method", method), zap.Int("attempt", attempt), zap.Error(err))
+
+			if werr := p.wait(ctx, attempt); werr != nil {
+				return err
+			}
+
+			// a fault from the previous attempt must not leak into the next one
+			resetResponse(res)
+		}
+	})
+}
+
+// retryREST returns a http.RoundTripper which retries read requests on
+// transient errors
+func retryREST(rt http.RoundTripper, p RetryPolicy) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !isReadRequest(req) || (req.Body != nil && req.GetBody == nil) {
+			return rt.RoundTrip(req)
+		}
+
+		ctx := req.Context()
+		for attempt := 1; ; attempt++ {
+			r := req
+			if attempt > 1 && req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r = req.Clone(ctx)
+				r.Body = body
+			}
+
+			res, err := rt.RoundTrip(r)
+			if attempt >= p.MaxAttempts || ctx.Err() != nil || !isTransientResponse(res, err) {
+				return res, err
+			}
+
+			logger.Get(ctx).Debug("retrying REST request",
+				zap.String("method", req.Method), zap.String("path", req.URL.Path), zap.Int("attempt", attempt))
+
+			if res != nil {
+				_, _ = io.Copy(io.Discard, res.Body)
+				_ = res.Body.Close()
+			}
+
+			if werr := p.wait(ctx, attempt); werr != nil {
+				return nil, werr
+			}
+		}
+	})
+}
+
+// resetResponse sets the response body to its zero value
+func resetResponse(res soap.HasFault) {
+	v := reflect.ValueOf(res)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
+
+// isTransient returns true if the SOAP error is likely to succeed on retry
+func isTransient(err error) bool {
+	if soap.IsSoapFault(err) {
+		f := soap.ToSoapFault(err)
+		return f.Code == "ServerFaultCode" && strings.Contains(strings.ToLower(f.String), "busy")
+	}
+
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		// soap.Client returns unexported status errors with the HTTP status as
+		// error message
+		if code, cerr := strconv.Atoi(strings.SplitN(ue.Err.Error(), " ", 2)[0]); cerr == nil {
+			return isTransientStatus(code)
+		}
+	}
+
+	return isTransientNetworkError(err)
+}
+
+// isTransientResponse returns true if the REST response or error is likely to
+// succeed on retry
+func isTransientResponse(res *http.Response, err error) bool {
+	if err != nil {
+		return isTransientNetworkError(err)
+	}
+	return isTransientStatus(res.StatusCode)
+}
+
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTransientNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
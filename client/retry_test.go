@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"gotest.tools/v3/assert"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+}
+
+func Test_retrySOAP(t *testing.T) {
+	connReset := &url.Error{Op: "Post", URL: "/sdk", Err: syscall.ECONNRESET}
+	unavailable := &url.Error{Op: "Post", URL: "/sdk", Err: errors.New("503 Service Unavailable")}
+	busy := soap.WrapSoapFault(&soap.Fault{Code: "ServerFaultCode", String: "The server is busy"})
+	invalid := soap.WrapSoapFault(&soap.Fault{Code: "ServerFaultCode", String: "invalid argument"})
+
+	testCases := []struct {
+		name         string
+		req          soap.HasFault
+		errs         []error
+		wantAttempts int32
+		wantErr      error
+	}{
+		{
+			name:         "retries read method on connection reset",
+			req:          &methods.RetrievePropertiesBody{},
+			errs:         []error{connReset, nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "retries read method on service unavailable",
+			req:          &methods.ReadNextEventsBody{},
+			errs:         []error{unavailable, unavailable, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "retries read method on busy fault",
+			req:          &methods.RetrievePropertiesExBody{},
+			errs:         []error{busy, nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "stops after max attempts",
+			req:          &methods.RetrievePropertiesBody{},
+			errs:         []error{connReset, connReset, connReset, nil},
+			wantAttempts: 3,
+			wantErr:      connReset,
+		},
+		{
+			name:         "does not retry permanent errors",
+			req:          &methods.RetrievePropertiesBody{},
+			errs:         []error{invalid, nil},
+			wantAttempts: 1,
+			wantErr:      invalid,
+		},
+		{
+			name:         "does not retry methods modifying the inventory",
+			req:          &methods.PowerOffVM_TaskBody{},
+			errs:         []error{connReset, nil},
+			wantAttempts: 1,
+			wantErr:      connReset,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			rt := soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+				i := atomic.AddInt32(&attempts, 1)
+				return tc.errs[i-1]
+			})
+
+			err := retrySOAP(rt, testRetryPolicy).RoundTrip(context.Background(), tc.req, tc.req)
+			if tc.wantErr != nil {
+				assert.Equal(t, err, tc.wantErr)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, atomic.LoadInt32(&attempts), tc.wantAttempts)
+		})
+	}
+
+	t.Run("stops retrying when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var attempts int32
+		rt := soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+			atomic.AddInt32(&attempts, 1)
+			cancel()
+			return connReset
+		})
+
+		err := retrySOAP(rt, testRetryPolicy).RoundTrip(ctx, &methods.RetrievePropertiesBody{}, &methods.RetrievePropertiesBody{})
+		assert.Equal(t, err, connReset)
+		assert.Equal(t, atomic.LoadInt32(&attempts), int32(1))
+	})
+
+	t.Run("resets response between attempts", func(t *testing.T) {
+		res := &methods.RetrievePropertiesBody{
+			Fault_: &soap.Fault{Code: "ServerFaultCode"},
+			Res:    &types.RetrievePropertiesResponse{},
+		}
+		resetResponse(res)
+		assert.Assert(t, res.Fault_ == nil)
+		assert.Assert(t, res.Res == nil)
+	})
+}
+
+func Test_retryREST(t *testing.T) {
+	newServer := func(t *testing.T, failures int32) (*httptest.Server, *int32) {
+		var attempts int32
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			b, _ := io.ReadAll(r.Body)
+			_, _ = w.Write(b)
+		}))
+		t.Cleanup(s.Close)
+		return s, &attempts
+	}
+
+	client := &http.Client{Transport: retryREST(http.DefaultTransport, testRetryPolicy)}
+
+	t.Run("retries GET on service unavailable", func(t *testing.T) {
+		s, attempts := newServer(t, 2)
+
+		res, err := client.Get(s.URL + "/rest/com/vmware/cis/tagging/tag")
+		assert.NilError(t, err)
+		assert.NilError(t, res.Body.Close())
+		assert.Equal(t, res.StatusCode, http.StatusOK)
+		assert.Equal(t, atomic.LoadInt32(attempts), int32(3))
+	})
+
+	t.Run("retries POST read action with body", func(t *testing.T) {
+		s, attempts := newServer(t, 1)
+
+		res, err := client.Post(s.URL+"/rest/com/vmware/cis/tagging/tag-association?~action=list-attached-tags",
+			"application/json", strings.NewReader(`{"object_id":"vm-1"}`))
+		assert.NilError(t, err)
+
+		b, err := io.ReadAll(res.Body)
+		assert.NilError(t, err)
+		assert.NilError(t, res.Body.Close())
+		assert.Equal(t, string(b), `{"object_id":"vm-1"}`)
+		assert.Equal(t, atomic.LoadInt32(attempts), int32(2))
+	})
+
+	t.Run("does not retry DELETE", func(t *testing.T) {
+		s, attempts := newServer(t, 1)
+
+		req, err := http.NewRequest(http.MethodDelete, s.URL+"/rest/com/vmware/cis/tagging/tag/id", nil)
+		assert.NilError(t, err)
+
+		res, err := client.Do(req)
+		assert.NilError(t, err)
+		assert.NilError(t, res.Body.Close())
+		assert.Equal(t, res.StatusCode, http.StatusServiceUnavailable)
+		assert.Equal(t, atomic.LoadInt32(attempts), int32(1))
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("validates policy", func(t *testing.T) {
+		assert.NilError(t, DefaultRetryPolicy.validate())
+		assert.ErrorContains(t, RetryPolicy{}.validate(), "max attempts")
+		assert.ErrorContains(t, RetryPolicy{MaxAttempts: 1, InitialBackoff: time.Second}.validate(), "backoff")
+	})
+
+	t.Run("bounds backoff", func(t *testing.T) {
+		p := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: 4 * time.Second}
+		for retry := 1; retry < 10; retry++ {
+			b := p.backoff(retry)
+			assert.Assert(t, b >= p.InitialBackoff/2)
+			assert.Assert(t, b <= p.MaxBackoff)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// rotationDebounce is the quiet period after the last change to the secret
+// path before credentials are reloaded. Kubernetes updates mounted secrets via
+// multiple file operations (atomic symlink swap).
+const rotationDebounce = time.Second
+
+// watchCredentials watches the given secret path and performs a new login on
+// the SOAP and REST sessions when the credentials change. The watcher stops
+// when ctx is cancelled.
+func (c *Client) watchCredentials(ctx context.Context, path string) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create file watcher: %w", err)
+	}
+
+	// watch the directory instead of the files to capture symlink swaps
+	if err = w.Add(path); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("watch secret path %q: %w", path, err)
+	}
+
+	log := logger.Get(ctx).With(zap.String("path", path))
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() {
+			_ = w.Close()
+		}()
+
+		debounce := time.NewTimer(rotationDebounce)
+		debounce.Stop()
+		defer debounce.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				log.Debug("secret path changed", zap.String("event", e.String()))
+				debounce.Reset(rotationDebounce)
+
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Error("watch secret path", zap.Error(err))
+
+			case <-debounce.C:
+				if err := c.rotateCredentials(ctx); err != nil {
+					log.Error("rotate credentials", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// rotateCredentials retrieves the credentials from the configured provider
+// and logs in again on the SOAP and REST sessions if they have changed. The
+// current sessions are kept if the login fails.
+func (c *Client) rotateCredentials(ctx context.Context) error {
+	creds, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieve credentials: %w", err)
+	}
+
+	replaced, err := c.login(ctx, creds)
+
+	// callbacks are invoked without holding the lock, e.g. to recreate event
+	// collectors
+	for _, api := range replaced {
+		c.sessionLostCallbacks(api, errSessionReplaced)
+		c.sessionRestoredCallbacks(api)
+	}
+
+	return err
+}
+
+// login replaces the current SOAP and REST sessions with new sessions created
+// with the given credentials if they have changed and returns the APIs of the
+// replaced sessions
+func (c *Client) login(ctx context.Context, creds Credentials) ([]API, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if creds == c.current {
+		return nil, nil
+	}
+
+	log := logger.Get(ctx)
+	log.Info("credentials changed, logging in with new credentials", zap.String("username", creds.Username))
+
+	var (
+		replaced []API
+		result   error
+	)
+
+	if err := c.loginSOAP(ctx, creds); err != nil {
+		result = multierror.Append(result, err)
+	} else {
+		replaced = append(replaced, APISOAP)
+	}
+
+	if c.HasREST() {
+		if err := c.loginREST(ctx, creds); err != nil {
+			result = multierror.Append(result, err)
+		} else {
+			replaced = append(replaced, APIREST)
+		}
+	}
+
+	if result != nil {
+		return replaced, result
+	}
+	c.current = creds
+
+	log.Info("credentials rotated", zap.String("username", creds.Username))
+	return replaced, nil
+}
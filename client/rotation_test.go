@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+func TestClient_watchCredentials(t *testing.T) {
+	dir := tempDir(t)
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(dir)
+		assert.NilError(t, err)
+	})
+
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		core, logs := observer.New(zapcore.ErrorLevel)
+		ctx = logger.Set(ctx, zap.New(core))
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithSecretPath(dir),
+		)
+		assert.NilError(t, err)
+
+		lost := make(chan API, 2)
+		restored := make(chan API, 2)
+		c.OnSessionLost(func(api API, err error) {
+			assert.Assert(t, errors.Is(err, errSessionReplaced))
+			lost <- api
+		})
+		c.OnSessionRestored(func(api API) {
+			restored <- api
+		})
+
+		t.Run("replaces sessions", func(t *testing.T) {
+			before, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			restID := c.REST.SessionID()
+
+			err = os.WriteFile(filepath.Join(dir, passwordFileKey), []byte("rotated"), 0o600)
+			assert.NilError(t, err)
+
+			poll.WaitOn(t, func(t poll.LogT) poll.Result {
+				c.mu.Lock()
+				defer c.mu.Unlock()
+
+				if c.current.Password != "rotated" {
+					return poll.Continue("credentials not rotated")
+				}
+				return poll.Success()
+			}, poll.WithTimeout(5*rotationDebounce), poll.WithDelay(100*time.Millisecond))
+
+			after, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, after != nil)
+			assert.Assert(t, before.Key != after.Key)
+
+			s, err := c.REST.Session(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, s != nil)
+			assert.Assert(t, c.REST.SessionID() != restID)
+
+			// the previous sessions are logged out
+			rc := rest.NewClient(vimclient)
+			rc.SessionID(restID)
+			s, err = rc.Session(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, s == nil)
+
+			assert.Equal(t, waitFor(t, lost), APISOAP)
+			assert.Equal(t, waitFor(t, restored), APISOAP)
+			assert.Equal(t, waitFor(t, lost), APIREST)
+			assert.Equal(t, waitFor(t, restored), APIREST)
+		})
+
+		t.Run("keeps sessions if login fails", func(t *testing.T) {
+			before, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			restID := c.REST.SessionID()
+
+			// the simulator rejects empty passwords
+			err = os.WriteFile(filepath.Join(dir, passwordFileKey), []byte(""), 0o600)
+			assert.NilError(t, err)
+
+			poll.WaitOn(t, func(t poll.LogT) poll.Result {
+				if logs.FilterMessage("rotate credentials").Len() == 0 {
+					return poll.Continue("credentials not rotated")
+				}
+				return poll.Success()
+			}, poll.WithTimeout(5*rotationDebounce), poll.WithDelay(100*time.Millisecond))
+			entry := logs.FilterMessage("rotate credentials").All()[0]
+			assert.Assert(t, strings.Contains(entry.ContextMap()["error"].(string), "login SOAP session"))
+
+			after, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, after != nil)
+			assert.Equal(t, before.Key, after.Key)
+
+			s, err := c.REST.Session(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, s != nil)
+			assert.Equal(t, c.REST.SessionID(), restID)
+
+			c.mu.Lock()
+			assert.Equal(t, c.current.Password, "rotated")
+			c.mu.Unlock()
+
+			assert.Equal(t, len(lost), 0)
+			assert.Equal(t, len(restored), 0)
+		})
+
+		err = c.Logout()
+		assert.NilError(t, err)
+
+		return nil
+	})
+}
@@ -0,0 +1,310 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+const (
+	sessionRecoveryBackoff    = time.Second // initial backoff between login attempts
+	sessionRecoveryMaxBackoff = time.Minute // upper bound for the backoff
+)
+
+// API identifies a vCenter API session
+type API string
+
+const (
+	// APISOAP is the vCenter SOAP API session
+	APISOAP API = "SOAP"
+	// APIREST is the vCenter REST (VAPI) API session
+	APIREST API = "REST"
+)
+
+// SessionLostFunc is called when the session of the given API is lost or
+// replaced. err is the error which indicated the lost session or
+// errSessionReplaced.
+type SessionLostFunc func(api API, err error)
+
+// SessionRestoredFunc is called when the session of the given API is
+// re-established or replaced. Server-side objects bound to the previous
+// session, e.g. event history collectors, must be recreated.
+type SessionRestoredFunc func(api API)
+
+// errSessionReplaced is passed to SessionLostFunc when a session is replaced
+// with a new session, e.g. after the credentials changed
+var errSessionReplaced = errors.New("session replaced with new credentials")
+
+// sessionLost signals a lost session to the recovery loop
+type sessionLost struct {
+	api API
+	err error
+}
+
+// OnSessionLost registers a callback which is invoked when a SOAP or REST
+// session is lost or replaced, e.g. after the credentials changed. Callbacks
+// are invoked sequentially and must not block.
+func (c *Client) OnSessionLost(f SessionLostFunc) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.onLost = append(c.onLost, f)
+}
+
+// OnSessionRestored registers a callback which is invoked when a lost SOAP or
+// REST session is re-established or a session is replaced, e.g. after the
+// credentials changed. Callbacks are invoked sequentially and must not block.
+func (c *Client) OnSessionRestored(f SessionRestoredFunc) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.onRestored = append(c.onRestored, f)
+}
+
+// sessionLostCallbacks invokes the callbacks registered with OnSessionLost
+func (c *Client) sessionLostCallbacks(api API, err error) {
+	c.hooksMu.Lock()
+	onLost := append([]SessionLostFunc(nil), c.onLost...)
+	c.hooksMu.Unlock()
+
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	for _, f := range onLost {
+		f(api, err)
+	}
+}
+
+// sessionRestoredCallbacks invokes the callbacks registered with
+// OnSessionRestored
+func (c *Client) sessionRestoredCallbacks(api API) {
+	c.hooksMu.Lock()
+	onRestored := append([]SessionRestoredFunc(nil), c.onRestored...)
+	c.hooksMu.Unlock()
+
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	for _, f := range onRestored {
+		f(api)
+	}
+}
+
+// notifySessionLost signals a lost session to the recovery loop without
+// blocking the caller, i.e. the keep-alive handler
+func (c *Client) notifySessionLost(api API, err error) {
+	select {
+	case c.lost <- sessionLost{api: api, err: err}:
+	default:
+		// recovery already pending
+	}
+}
+
+// recoverSessions re-establishes lost sessions until ctx is cancelled
+func (c *Client) recoverSessions(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case l := <-c.lost:
+				c.recoverSession(ctx, l)
+			}
+		}
+	}()
+}
+
+// recoverSession re-establishes the lost session with bounded exponential
+// backoff
+func (c *Client) recoverSession(ctx context.Context, l sessionLost) {
+	log := logger.Get(ctx).With(zap.String("api", string(l.api)))
+
+	// a duplicate signal might have been queued while the session was recovered
+	if c.sessionActive(ctx, l.api) {
+		log.Debug("session already active, skipping recovery")
+		return
+	}
+
+	log.Warn("session lost, attempting recovery", zap.Error(l.err))
+	c.sessionLostCallbacks(l.api, l.err)
+
+	backoff := sessionRecoveryBackoff
+	for attempt := 1; ; attempt++ {
+		err := c.relogin(ctx, l.api)
+		if err == nil {
+			break
+		}
+
+		log.Error("recover session", zap.Error(err), zap.Int("attempt", attempt), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > sessionRecoveryMaxBackoff {
+			backoff = sessionRecoveryMaxBackoff
+		}
+	}
+
+	log.Info("session restored")
+	c.sessionRestoredCallbacks(l.api)
+}
+
+// relogin retrieves the current credentials and logs in again on the session
+// of the given API
+func (c *Client) relogin(ctx context.Context, api API) error {
+	creds, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieve credentials: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch api {
+	case APISOAP:
+		err = c.loginSOAP(ctx, creds)
+	case APIREST:
+		err = c.loginREST(ctx, creds)
+	default:
+		err = fmt.Errorf("unknown api %q", api)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.current = creds
+	return nil
+}
+
+// sessionActive returns true if the session of the given API is authenticated
+func (c *Client) sessionActive(ctx context.Context, api API) bool {
+	switch api {
+	case APISOAP:
+		s, err := c.SOAP.SessionManager.UserSession(ctx)
+		return err == nil && s != nil
+	case APIREST:
+		if !c.HasREST() {
+			return false
+		}
+		s, err := c.restSession(ctx)
+		return err == nil && s != nil
+	default:
+		return false
+	}
+}
+
+// restSession returns the REST session or nil if not authenticated. The
+// session is retrieved bypassing the keep-alive handler which treats requests
+// to the session endpoint as login, i.e. restarts a stopped keep-alive.
+func (c *Client) restSession(ctx context.Context) (*rest.Session, error) {
+	c.restSessions.SessionID(c.REST.SessionID())
+	return c.restSessions.Session(ctx)
+}
+
+// loginSOAP replaces the current SOAP session with a new session created with
+// the given credentials. The new session is created with a separate client,
+// i.e. the current session is kept if the login fails, and the current session
+// is logged out afterwards.
+func (c *Client) loginSOAP(ctx context.Context, creds Credentials) error {
+	vc := c.SOAP.Client
+	u := vc.URL()
+
+	// vCenter rejects logins on an authenticated session, i.e. the separate
+	// client shares the transport but not the session cookie
+	sc := soap.NewClient(u, false)
+	sc.Transport = vc.Client.Transport
+	sc.Namespace = vc.Namespace
+	sc.Version = vc.Version
+	sc.UserAgent = vc.UserAgent
+
+	m := session.NewManager(&vim25.Client{
+		Client:         sc,
+		ServiceContent: vc.ServiceContent,
+		RoundTripper:   c.soapMiddleware(sc),
+	})
+	if err := m.Login(ctx, creds.userinfo()); err != nil {
+		return fmt.Errorf("login SOAP session: %w", redact(err))
+	}
+
+	previous := vc.Jar.Cookies(u)
+	vc.Jar.SetCookies(u, sc.Jar.Cookies(u))
+	c.metrics.setSessionActive(APISOAP, true)
+
+	// the previous session might already be invalid so logout errors are
+	// ignored
+	sc.Jar.SetCookies(u, previous)
+	if err := m.Logout(ctx); err != nil {
+		logger.Get(ctx).Debug("logout previous SOAP session", zap.Error(err))
+	}
+
+	if c.cache != nil {
+		if err := c.cache.saveSOAP(c.SOAP.Client, creds.Username); err != nil {
+			logger.Get(ctx).Warn("save SOAP session to cache", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// loginREST replaces the current REST session with a new session created with
+// the given credentials. The new session is created with a separate client,
+// i.e. the current session is kept if the login fails, and the current session
+// is logged out afterwards.
+func (c *Client) loginREST(ctx context.Context, creds Credentials) error {
+	// the separate client bypasses the keep-alive handler which treats
+	// requests to the session endpoint as login and logout
+	rc := rest.NewClient(c.SOAP.Client)
+	rc.Transport = c.restSessions.Transport
+	if err := rc.Login(ctx, creds.userinfo()); err != nil {
+		return fmt.Errorf("login REST session: %w", redact(err))
+	}
+
+	previous := c.REST.SessionID()
+	c.REST.SessionID(rc.SessionID())
+	c.metrics.setSessionActive(APIREST, true)
+
+	// the previous session might already be invalid so logout errors are
+	// ignored
+	rc.SessionID(previous)
+	if err := rc.Logout(ctx); err != nil {
+		logger.Get(ctx).Debug("logout previous REST session", zap.Error(err))
+	}
+
+	if c.cache != nil {
+		if err := c.cache.saveREST(c.REST, creds.Username); err != nil {
+			logger.Get(ctx).Warn("save REST session to cache", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// isNotAuthenticated returns true if err is a NotAuthenticated SOAP fault
+func isNotAuthenticated(err error) bool {
+	var f types.HasFault
+	if errors.As(err, &f) {
+		if _, ok := f.Fault().(*types.NotAuthenticated); ok {
+			return true
+		}
+	}
+
+	if soap.IsSoapFault(err) {
+		switch soap.ToSoapFault(err).VimFault().(type) {
+		case types.NotAuthenticated, *types.NotAuthenticated:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"gotest.tools/v3/assert"
+)
+
+func TestClient_recoverSessions(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithKeepaliveInterval(100*time.Millisecond),
+		)
+		assert.NilError(t, err)
+
+		lost := make(chan API, 2)
+		restored := make(chan API, 2)
+		c.OnSessionLost(func(api API, err error) {
+			assert.Assert(t, err != nil)
+			lost <- api
+		})
+		c.OnSessionRestored(func(api API) {
+			restored <- api
+		})
+
+		t.Run("recovers SOAP session", func(t *testing.T) {
+			s, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+
+			// terminate the session server-side
+			err = session.NewManager(vimclient).TerminateSession(ctx, []string{s.Key})
+			assert.NilError(t, err)
+
+			assert.Equal(t, waitFor(t, lost), APISOAP)
+			assert.Equal(t, waitFor(t, restored), APISOAP)
+
+			_, err = methods.GetCurrentTime(ctx, c.SOAP)
+			assert.NilError(t, err)
+		})
+
+		t.Run("recovers REST session", func(t *testing.T) {
+			// logout the session with another client to not stop the keep-alive
+			rc := rest.NewClient(vimclient)
+			rc.SessionID(c.REST.SessionID())
+			assert.NilError(t, rc.Logout(ctx))
+
+			assert.Equal(t, waitFor(t, lost), APIREST)
+			assert.Equal(t, waitFor(t, restored), APIREST)
+
+			s, err := c.REST.Session(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, s != nil)
+		})
+
+		err = c.Logout()
+		assert.NilError(t, err)
+
+		return nil
+	})
+}
+
+func Test_isNotAuthenticated(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		err := session.NewManager(vimclient).Logout(ctx)
+		assert.NilError(t, err)
+
+		_, err = methods.GetCurrentTime(ctx, vimclient)
+		assert.Assert(t, isNotAuthenticated(err))
+		assert.Assert(t, !isNotAuthenticated(errors.New(http.StatusText(http.StatusUnauthorized))))
+
+		return nil
+	})
+}
+
+func waitFor(t *testing.T, ch <-chan API) API {
+	t.Helper()
+
+	select {
+	case api := <-ch:
+		return api
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for session callback")
+		return ""
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// newTLSConfig returns the TLS configuration for the SOAP and REST clients
+// based on the given options. Returns nil if the defaults should be used.
+func newTLSConfig(o *options) (*tls.Config, error) {
+	if o.tlsConfig == nil && o.config.CAPath == "" && o.config.Thumbprint == "" {
+		return nil, nil
+	}
+
+	cfg := new(tls.Config)
+	if o.tlsConfig != nil {
+		cfg = o.tlsConfig.Clone()
+	}
+
+	if o.config.Insecure {
+		cfg.InsecureSkipVerify = true
+		return cfg, nil
+	}
+
+	if o.config.CAPath != "" {
+		pem, err := os.ReadFile(o.config.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM(pem); !ok {
+			return nil, fmt.Errorf("no valid certificates found in CA bundle %q", o.config.CAPath)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.config.Thumbprint != "" {
+		pin, err := parseThumbprint(o.config.Thumbprint)
+		if err != nil {
+			return nil, err
+		}
+
+		// without a CA bundle the pinned certificate is the only trust anchor
+		if o.config.CAPath == "" {
+			cfg.InsecureSkipVerify = true
+		}
+		cfg.VerifyConnection = verifyThumbprint(pin)
+	}
+
+	return cfg, nil
+}
+
+// thumbprintError is returned if the server certificate does not match the
+// pinned thumbprint
+type thumbprintError struct {
+	got  []byte // nil if the server did not present a certificate
+	want []byte
+}
+
+func (e *thumbprintError) Error() string {
+	if e.got == nil {
+		return "server did not present a certificate"
+	}
+	return fmt.Sprintf("server certificate thumbprint %s does not match pinned thumbprint %s",
+		formatThumbprint(e.got), formatThumbprint(e.want))
+}
+
+// verifyThumbprint returns a function which verifies that the SHA-256
+// thumbprint of the server leaf certificate matches the given thumbprint
+func verifyThumbprint(thumbprint []byte) func(cs tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return &thumbprintError{want: thumbprint}
+		}
+
+		sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), hex.EncodeToString(thumbprint)) {
+			return &thumbprintError{got: sum[:], want: thumbprint}
+		}
+
+		return nil
+	}
+}
+
+// parseThumbprint parses a hex-encoded SHA-256 thumbprint, optionally
+// separated by colons, e.g. as shown in the vSphere Client
+func parseThumbprint(s string) ([]byte, error) {
+	tp, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	if err != nil {
+		return nil, fmt.Errorf("parse thumbprint: %w", err)
+	}
+
+	if len(tp) != sha256.Size {
+		return nil, fmt.Errorf("thumbprint must be a SHA-256 hash (%d bytes), got %d bytes", sha256.Size, len(tp))
+	}
+
+	return tp, nil
+}
+
+// formatThumbprint formats the thumbprint as upper case hex separated by
+// colons
+func formatThumbprint(tp []byte) string {
+	parts := make([]string, len(tp))
+	for i, b := range tp {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewClientTLS(t *testing.T) {
+	model := simulator.VPX()
+	defer model.Remove()
+
+	err := model.Create()
+	assert.NilError(t, err)
+
+	model.Service.TLS = new(tls.Config)
+	model.Service.RegisterEndpoints = true
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	caFile, err := s.CertificateFile()
+	assert.NilError(t, err)
+
+	sum := sha256.Sum256(s.Certificate().Raw)
+	thumbprint := formatThumbprint(sum[:])
+	wrongThumbprint := strings.Repeat("AB:", sha256.Size-1) + "AB"
+
+	testCases := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{
+			name:    "fails with untrusted certificate",
+			opts:    nil,
+			wantErr: "certificate",
+		},
+		{
+			name: "succeeds with CA bundle",
+			opts: []Option{WithCAPath(caFile)},
+		},
+		{
+			name: "succeeds with pinned thumbprint",
+			opts: []Option{WithThumbprint(thumbprint)},
+		},
+		{
+			name: "succeeds with lower case thumbprint without separators",
+			opts: []Option{WithThumbprint(strings.ToLower(strings.ReplaceAll(thumbprint, ":", "")))},
+		},
+		{
+			name: "succeeds with CA bundle and pinned thumbprint",
+			opts: []Option{WithCAPath(caFile), WithThumbprint(thumbprint)},
+		},
+		{
+			name:    "fails with wrong thumbprint",
+			opts:    []Option{WithThumbprint(wrongThumbprint)},
+			wantErr: "does not match pinned thumbprint",
+		},
+		{
+			name:    "fails with CA bundle and wrong thumbprint",
+			opts:    []Option{WithCAPath(caFile), WithThumbprint(wrongThumbprint)},
+			wantErr: "does not match pinned thumbprint",
+		},
+		{
+			name:    "fails with invalid thumbprint",
+			opts:    []Option{WithThumbprint("AB:CD")},
+			wantErr: "thumbprint must be a SHA-256 hash",
+		},
+		{
+			name:    "fails with insecure and thumbprint",
+			opts:    []Option{WithInsecure(true), WithThumbprint(thumbprint)},
+			wantErr: "insecure must not be combined",
+		},
+		{
+			name:    "fails with invalid CA bundle",
+			opts:    []Option{WithCAPath(os.DevNull)},
+			wantErr: "no valid certificates found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			opts := []Option{WithURL(s.URL.String()), WithCredentials("user", "pass")}
+			opts = append(opts, tc.opts...)
+
+			c, err := New(ctx, opts...)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.NilError(t, c.Logout())
+		})
+	}
+}
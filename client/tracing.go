@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/embano1/vsphere/client"
+
+// span attribute keys
+const (
+	attrHost       = attribute.Key("vcenter.host")
+	attrAPI        = attribute.Key("vcenter.api")
+	attrMethod     = attribute.Key("vcenter.method")
+	attrFault      = attribute.Key("vcenter.fault")
+	attrStatusCode = attribute.Key("http.status_code")
+)
+
+// traceSOAP returns a soap.RoundTripper which records a span per SOAP method.
+// The parent span is taken from the request context.
+func traceSOAP(rt soap.RoundTripper, tracer trace.Tracer, host string) soap.RoundTripper {
+	return soapRoundTripperFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		method := soapMethod(req)
+		ctx, span := tracer.Start(ctx, "SOAP "+method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrHost.String(host), attrAPI.String(string(APISOAP)), attrMethod.String(method)),
+		)
+		defer span.End()
+
+		err := rt.RoundTrip(ctx, req, res)
+		if err != nil {
+			span.SetAttributes(attrFault.String(soapCode(err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	})
+}
+
+// traceREST returns a http.RoundTripper which records a span per REST request
+// named by HTTP method and path template. The parent span is taken from the
+// request context.
+func traceREST(rt http.RoundTripper, tracer trace.Tracer, host string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		method := req.Method + " " + pathTemplate(req.URL.Path)
+		ctx, span := tracer.Start(req.Context(), "REST "+method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrHost.String(host), attrAPI.String(string(APIREST)), attrMethod.String(method)),
+		)
+		defer span.End()
+
+		res, err := rt.RoundTrip(req.WithContext(ctx))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return res, err
+		}
+
+		span.SetAttributes(attrStatusCode.Int(res.StatusCode))
+		if res.StatusCode >= http.StatusBadRequest {
+			span.SetAttributes(attrFault.String(http.StatusText(res.StatusCode)))
+			span.SetStatus(codes.Error, res.Status)
+		}
+		return res, nil
+	})
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gotest.tools/v3/assert"
+)
+
+func TestClientTracing(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		sr := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithTracerProvider(tp),
+		)
+		assert.NilError(t, err)
+
+		ctx, parent := tp.Tracer("test").Start(ctx, "handler")
+
+		_, err = methods.GetCurrentTime(ctx, c.SOAP)
+		assert.NilError(t, err)
+
+		_, err = methods.Destroy_Task(ctx, c.SOAP, &types.Destroy_Task{
+			This: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-unknown"},
+		})
+		assert.Assert(t, err != nil)
+
+		_, err = c.Tags.GetTag(ctx, "urn:vmomi:InventoryServiceTag:unknown:GLOBAL")
+		assert.Assert(t, err != nil)
+
+		parent.End()
+		assert.NilError(t, c.Logout())
+
+		spans := make(map[string]sdktrace.ReadOnlySpan)
+		for _, s := range sr.Ended() {
+			if s.Parent().SpanID() == parent.SpanContext().SpanID() {
+				spans[s.Name()] = s
+			}
+		}
+
+		t.Run("SOAP method span", func(t *testing.T) {
+			s, ok := spans["SOAP CurrentTime"]
+			assert.Assert(t, ok, "span not found: %v", spans)
+			assert.Equal(t, s.SpanKind(), trace.SpanKindClient)
+			assert.Equal(t, attrValue(s, attrHost), vimclient.URL().Host)
+			assert.Equal(t, attrValue(s, attrAPI), string(APISOAP))
+			assert.Equal(t, attrValue(s, attrMethod), "CurrentTime")
+			assert.Equal(t, s.Status().Code, codes.Unset)
+		})
+
+		t.Run("SOAP method span with fault", func(t *testing.T) {
+			s, ok := spans["SOAP Destroy_Task"]
+			assert.Assert(t, ok, "span not found: %v", spans)
+			assert.Equal(t, attrValue(s, attrFault), "ManagedObjectNotFound")
+			assert.Equal(t, s.Status().Code, codes.Error)
+		})
+
+		t.Run("REST request span with error", func(t *testing.T) {
+			s, ok := spans["REST GET /rest/com/vmware/cis/tagging/tag/{id}"]
+			assert.Assert(t, ok, "span not found: %v", spans)
+			assert.Equal(t, attrValue(s, attrAPI), string(APIREST))
+			assert.Equal(t, attrValue(s, attrStatusCode), "404")
+			assert.Equal(t, attrValue(s, attrFault), "Not Found")
+			assert.Equal(t, s.Status().Code, codes.Error)
+		})
+
+		return nil
+	})
+}
+
+func attrValue(s sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, a := range s.Attributes() {
+		if a.Key == key {
+			return a.Value.Emit()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/vmware/govmomi/vim25/soap"
+
+	"github.com/embano1/vsphere/logger"
+)
+
+// soapMiddleware wraps the SOAP round-tripper with the configured middleware
+// and sets the operation ID. The keep-alive handler wraps the returned
+// round-tripper.
+func soapMiddleware(rt soap.RoundTripper, o *options) soap.RoundTripper {
+	if o.limiter != nil {
+		rt = limitSOAP(rt, o.limiter)
+	}
+	if o.retry != nil {
+		rt = retrySOAP(rt, *o.retry)
+	}
+	if o.metrics != nil {
+		rt = instrumentSOAP(rt, o.metrics)
+	}
+	if o.tracer != nil {
+		rt = traceSOAP(rt, o.tracer, o.host)
+	}
+	if o.config.ReadOnly {
+		rt = readOnlySOAP(rt)
+	}
+	if o.audit != nil {
+		rt = auditSOAP(rt, o.audit, o.host)
+	}
+	return operationIDSOAP(rt)
+}
+
+// restMiddleware wraps the REST round-tripper with the configured middleware
+// and sets the operation ID. The keep-alive handler wraps the returned
+// round-tripper.
+func restMiddleware(rt http.RoundTripper, o *options) http.RoundTripper {
+	if o.limiter != nil {
+		rt = limitREST(rt, o.limiter)
+	}
+	if o.retry != nil {
+		rt = retryREST(rt, *o.retry)
+	}
+	if o.metrics != nil {
+		rt = instrumentREST(rt, o.metrics)
+	}
+	if o.tracer != nil {
+		rt = traceREST(rt, o.tracer, o.host)
+	}
+	if o.config.ReadOnly {
+		rt = readOnlyREST(rt)
+	}
+	if o.audit != nil {
+		rt = auditREST(rt, o.audit, o.host)
+	}
+	return operationIDREST(rt)
+}
+
+// configureTransport applies the TLS configuration and timeouts to the HTTP
+// transport of the SOAP client. The REST client shares the transport and thus
+// inherits these settings.
+func configureTransport(sc *soap.Client, o *options) {
+	t := sc.DefaultTransport()
+
+	if o.transportTLS != nil {
+		t.TLSClientConfig = o.transportTLS.Clone()
+	}
+
+	dialer := &net.Dialer{Timeout: o.config.DialTimeout}
+	if o.config.DialTimeout > 0 {
+		t.DialContext = dialer.DialContext
+	}
+
+	// the soap client dials TLS connections itself, i.e. the transport dial and
+	// handshake timeouts do not apply
+	if o.config.DialTimeout > 0 || o.config.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = o.config.TLSHandshakeTimeout
+		t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialTLS(ctx, dialer, t.TLSClientConfig, o, network, addr)
+		}
+	}
+
+	t.ResponseHeaderTimeout = o.config.ResponseTimeout
+}
+
+// dialTLS dials a TLS connection with the configured dial and handshake
+// timeouts
+func dialTLS(ctx context.Context, dialer *net.Dialer, cfg *tls.Config, o *options, network, addr string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg == nil {
+		cfg = new(tls.Config)
+	} else {
+		cfg = cfg.Clone()
+	}
+
+	if cfg.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		cfg.ServerName = host
+	}
+
+	if o.config.TLSHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.TLSHandshakeTimeout)
+		defer cancel()
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+	if err = tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// detachedContext returns a context for the keep-alive handlers and background
+// goroutines of a client which is not cancelled with ctx but carries over its
+// logger
+func detachedContext(ctx context.Context) context.Context {
+	return logger.Set(context.Background(), logger.Get(ctx))
+}
+
+// loginContext returns a child context of ctx bound by the configured login
+// timeout
+func loginContext(ctx context.Context, o *options) (context.Context, context.CancelFunc) {
+	if o.config.LoginTimeout > 0 {
+		return context.WithTimeout(ctx, o.config.LoginTimeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewClientTimeouts(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	t.Run("fails with tls handshake timeout", func(t *testing.T) {
+		// accepts connections but never completes a handshake
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			_ = l.Close()
+		})
+
+		go func() {
+			var conns []net.Conn
+			defer func() {
+				for _, c := range conns {
+					_ = c.Close()
+				}
+			}()
+
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				conns = append(conns, conn)
+			}
+		}()
+
+		start := time.Now()
+		_, err = New(context.Background(),
+			WithURL("https://"+l.Addr().String()),
+			WithCredentials("user", "pass"),
+			WithTLSHandshakeTimeout(timeout),
+		)
+		assert.ErrorContains(t, err, "deadline exceeded")
+		assert.Assert(t, time.Since(start) < 10*timeout)
+	})
+
+	// never responds within the test
+	hanging := func(t *testing.T) *httptest.Server {
+		done := make(chan struct{})
+		s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-done
+		}))
+		t.Cleanup(func() {
+			close(done)
+			s.Close()
+		})
+		return s
+	}
+
+	t.Run("fails with response timeout", func(t *testing.T) {
+		s := hanging(t)
+
+		_, err := New(context.Background(),
+			WithURL(s.URL),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithResponseTimeout(timeout),
+		)
+		assert.ErrorContains(t, err, "timeout awaiting response headers")
+	})
+
+	t.Run("fails with login timeout", func(t *testing.T) {
+		s := hanging(t)
+
+		_, err := New(context.Background(),
+			WithURL(s.URL),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithLoginTimeout(timeout),
+		)
+		assert.ErrorContains(t, err, "deadline exceeded")
+	})
+
+	t.Run("bounds construction with login timeout", func(t *testing.T) {
+		s := hanging(t)
+
+		// blocks until the deadline of the construction
+		slow := CredentialProviderFunc(func(ctx context.Context) (Credentials, error) {
+			select {
+			case <-ctx.Done():
+				return Credentials{}, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return Credentials{Username: "user", Password: "pass"}, nil
+			}
+		})
+
+		start := time.Now()
+		_, err := New(context.Background(),
+			WithURL(s.URL),
+			WithInsecure(true),
+			WithCredentialProvider(slow),
+			WithLoginTimeout(timeout),
+		)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Assert(t, time.Since(start) < 10*timeout)
+	})
+
+	t.Run("succeeds with timeouts", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+			c, err := New(ctx,
+				WithURL(vimclient.URL().String()),
+				WithInsecure(true),
+				WithCredentials("user", "pass"),
+				WithDialTimeout(time.Second),
+				WithTLSHandshakeTimeout(time.Second),
+				WithResponseTimeout(time.Second),
+				WithLoginTimeout(time.Second),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, c.Logout())
+
+			return nil
+		})
+	})
+}
@@ -8,17 +8,21 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
-	"github.com/kelseyhightower/envconfig"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/session/keepalive"
 	"github.com/vmware/govmomi/task"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/soap"
@@ -36,11 +40,47 @@ const (
 // Client is a combined vCenter SOAP and REST (VAPI) client with fields to
 // directly access commonly used managers
 type Client struct {
-	SOAP   *govmomi.Client
+	SOAP *govmomi.Client
+	// REST and Tags are nil if the REST API is not enabled (see HasREST)
 	REST   *rest.Client
 	Tags   *tags.Manager
 	Tasks  *task.Manager
 	Events *event.Manager
+
+	Views      *view.Manager
+	Properties *property.Collector
+	// CustomFields and Alarms are nil if not supported by the endpoint, e.g.
+	// standalone ESXi hosts
+	CustomFields *object.CustomFieldsManager
+	Alarms       *AlarmManager
+	// Finder uses the configured default datacenter, if any
+	Finder *find.Finder
+
+	credentials CredentialProvider
+	mu          sync.Mutex  // guards current and login
+	current     Credentials // credentials of the active sessions
+
+	// restSessions retrieves the REST session bypassing the keep-alive handler
+	restSessions *rest.Client
+	// soapMiddleware wraps the round-tripper of separate SOAP clients, e.g.
+	// used to log in with changed credentials
+	soapMiddleware func(rt soap.RoundTripper) soap.RoundTripper
+
+	cache      *sessionCache
+	keepalives []keepaliveHandler
+	metrics    *metrics
+	info       Info
+
+	lost       chan sessionLost
+	hooksMu    sync.Mutex // guards onLost and onRestored
+	onLost     []SessionLostFunc
+	onRestored []SessionRestoredFunc
+	notifyMu   sync.Mutex // serializes the invocation of callbacks
+
+	cancel  context.CancelFunc // stops background goroutines
+	wg      sync.WaitGroup
+	closeMu sync.Mutex // serializes Close
+	closed  bool       // true if Close succeeded
 }
 
 // Config configures the vsphere client via environment variables
@@ -48,111 +88,349 @@ type Config struct {
 	Insecure   bool   `envconfig:"VCENTER_INSECURE" default:"false"`
 	Address    string `envconfig:"VCENTER_URL" required:"true"`
 	SecretPath string `envconfig:"VCENTER_SECRET_PATH" required:"true" default:"/var/bindings/vsphere"`
+	CAPath     string `envconfig:"VCENTER_CA_PATH"`
+	Thumbprint string `envconfig:"VCENTER_THUMBPRINT"`
+	// DisableREST disables the REST API which is always disabled for
+	// standalone ESXi hosts
+	DisableREST bool `envconfig:"VCENTER_DISABLE_REST" default:"false"`
+	// Datacenter is the default datacenter of the finder if set
+	Datacenter string `envconfig:"VCENTER_DATACENTER"`
+	// ReadOnly rejects SOAP methods and REST requests which might modify the
+	// inventory
+	ReadOnly bool `envconfig:"VCENTER_READ_ONLY" default:"false"`
+	// SessionCachePath enables the session cache if set
+	SessionCachePath string `envconfig:"VCENTER_SESSION_CACHE_PATH"`
+
+	// RateLimit is the maximum number of SOAP and REST requests per second
+	// with bursts of up to RateBurst requests. Disabled if 0.
+	RateLimit float64 `envconfig:"VCENTER_RATE_LIMIT"`
+	RateBurst int     `envconfig:"VCENTER_RATE_BURST"`
+	// MaxInFlight is the maximum number of concurrent SOAP and REST requests.
+	// Disabled if 0.
+	MaxInFlight int `envconfig:"VCENTER_MAX_IN_FLIGHT"`
+
+	KeepaliveInterval time.Duration `envconfig:"VCENTER_KEEPALIVE_INTERVAL" default:"5m"`
+	// timeouts are disabled if 0
+	DialTimeout         time.Duration `envconfig:"VCENTER_DIAL_TIMEOUT"`
+	TLSHandshakeTimeout time.Duration `envconfig:"VCENTER_TLS_HANDSHAKE_TIMEOUT"`
+	ResponseTimeout     time.Duration `envconfig:"VCENTER_RESPONSE_TIMEOUT"`
+	LoginTimeout        time.Duration `envconfig:"VCENTER_LOGIN_TIMEOUT"`
+}
+
+// keepaliveHandler is implemented by the SOAP and REST keep-alive handlers
+type keepaliveHandler interface {
+	Stop()
 }
 
 // readKey reads the file from the secret path
-func readKey(key string) (string, error) {
-	var env Config
-	if err := envconfig.Process("", &env); err != nil {
+func readKey(path, key string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(path, key))
+	if err != nil {
 		return "", err
 	}
+	return string(data), nil
+}
 
-	data, err := ioutil.ReadFile(filepath.Join(env.SecretPath, key))
+// getCredentials retrieves the credentials from the configured provider
+func getCredentials(ctx context.Context, o *options) (Credentials, error) {
+	creds, err := o.credentials.Credentials(ctx)
 	if err != nil {
-		return "", err
+		return Credentials{}, fmt.Errorf("retrieve credentials: %w", err)
 	}
-	return string(data), nil
+	return creds, nil
 }
 
 // New returns a combined vCenter SOAP and REST (VAPI) client with active
-// keep-alive configured via environment variables. Commonly used managers are
-// exposed for quick access.
+// keep-alive. Commonly used managers are exposed for quick access.
+//
+// The client is configured with the given options. If no options are
+// specified, the client is configured via environment variables (see
+// WithEnv).
+//
+// ctx only bounds the construction, i.e. the logins and lookups, which is also
+// bounded by the login timeout, if set (see WithLoginTimeout). The keep-alive
+// handlers and background goroutines are bound to the lifetime of the client
+// and end on Close.
+//
+// Lost SOAP and REST sessions, e.g. detected by the keep-alive handlers, are
+// re-established with exponential backoff. Use OnSessionLost and
+// OnSessionRestored to get notified, e.g. to recreate event collectors.
+//
+// If the credentials are read from the secret path (default), the client
+// watches the secret path and transparently logs in again on the SOAP and REST
+// sessions when the credentials change, e.g. a rotated Kubernetes secret. The
+// sessions are only replaced if the login with the new credentials succeeds.
 //
 // A custom logger (zap.Logger) can be injected into the context via the logger
 // package.
 //
-// Use Logout() to release resources and perform a clean logout from vCenter.
-func New(ctx context.Context) (*Client, error) {
-	vclient, err := NewSOAP(ctx)
+// If the session cache is enabled (see WithSessionCache), valid sessions are
+// reused across restarts.
+//
+// Use Close() to release resources and perform a clean logout from vCenter.
+func New(ctx context.Context, opts ...Option) (*Client, error) {
+	o, err := newOptions(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("create vsphere SOAP client: %w", err)
+		return nil, newError(ErrConfig, "configure vsphere client", err)
 	}
 
-	rc, err := NewREST(ctx, vclient.Client)
+	// the login timeout bounds the whole construction while cleanup on failure
+	// uses ctx
+	loginCtx, cancelLogin := loginContext(ctx, o)
+	defer cancelLogin()
+
+	creds, err := getCredentials(loginCtx, o)
 	if err != nil {
-		return nil, fmt.Errorf("create vsphere REST client: %w", err)
+		return nil, newError(ErrCredentials, "create vsphere client", err)
 	}
 
+	// the keep-alive handlers and background goroutines outlive ctx which only
+	// bounds the login
+	lifecycle, cancel := context.WithCancel(detachedContext(ctx))
+
 	client := Client{
-		SOAP:   vclient,
-		REST:   rc,
-		Tags:   tags.NewManager(rc),
-		Tasks:  task.NewManager(vclient.Client),
-		Events: event.NewManager(vclient.Client),
+		credentials: o.credentials,
+		current:     creds,
+		cache:       o.cache,
+		metrics:     o.metrics,
+		lost:        make(chan sessionLost, 2), // one per API
+		cancel:      cancel,
+		soapMiddleware: func(rt soap.RoundTripper) soap.RoundTripper {
+			return soapMiddleware(rt, o)
+		},
+	}
+
+	vclient, err := newSOAP(loginCtx, lifecycle, o, creds, client.notifySessionLost)
+	if err != nil {
+		cancel()
+		return nil, newError(nil, "create vsphere SOAP client", err)
+	}
+
+	client.keepalives = []keepaliveHandler{vclient.Client.RoundTripper.(*keepalive.HandlerSOAP)}
+
+	// standalone ESXi hosts do not provide the REST API
+	if !o.config.DisableREST && vclient.IsVC() {
+		rc, sessions, err := newREST(loginCtx, lifecycle, vclient.Client, o, creds, client.notifySessionLost)
+		if err != nil {
+			cancel()
+			_ = vclient.Logout(ctx)
+			return nil, newError(nil, "create vsphere REST client", err)
+		}
+
+		client.keepalives = append(client.keepalives, rc.Transport.(*keepalive.HandlerREST))
+		client.REST = rc
+		client.restSessions = sessions
+		client.Tags = tags.NewManager(rc)
+	}
+
+	client.SOAP = vclient
+
+	if client.info, err = newInfo(vclient.ServiceContent.About); err != nil {
+		// version specific features are not available but the client is usable
+		logger.Get(ctx).Warn("parse endpoint version", zap.Error(err))
+	}
+
+	client.Tasks = task.NewManager(vclient.Client)
+	client.Events = event.NewManager(vclient.Client)
+	client.Views = view.NewManager(vclient.Client)
+	client.Properties = property.DefaultCollector(vclient.Client)
+
+	if err = client.setManagers(loginCtx, o); err != nil {
+		_ = client.Close(ctx)
+		return nil, err
+	}
+
+	client.recoverSessions(lifecycle)
+
+	if fc, ok := o.credentials.(*fileCredentials); ok {
+		if err = client.watchCredentials(lifecycle, fc.path); err != nil {
+			_ = client.Close(ctx)
+			return nil, newError(ErrCredentials, "watch credentials", err)
+		}
 	}
 
 	return &client, nil
 }
 
-// Logout attempts a clean logout from the various vCenter APIs
+// HasREST returns true if the REST API is enabled, i.e. REST and Tags are set.
+// The REST API is not available on standalone ESXi hosts or if disabled with
+// WithoutREST.
+func (c *Client) HasREST() bool {
+	return c.REST != nil
+}
+
+// setManagers sets the managers which might not be supported by the endpoint
+// or require a lookup. Errors are returned as *Error.
+func (c *Client) setManagers(ctx context.Context, o *options) error {
+	var err error
+
+	c.CustomFields, err = object.GetCustomFieldsManager(c.SOAP.Client)
+	if err != nil && !errors.Is(err, object.ErrNotSupported) {
+		return newError(nil, "create custom fields manager", err)
+	}
+
+	c.Alarms, err = NewAlarmManager(c.SOAP.Client)
+	if err != nil && !errors.Is(err, object.ErrNotSupported) {
+		return newError(nil, "create alarm manager", err)
+	}
+
+	c.Finder = find.NewFinder(c.SOAP.Client)
+	if o.config.Datacenter != "" {
+		dc, err := c.Finder.Datacenter(ctx, o.config.Datacenter)
+		if err != nil {
+			return newError(ErrConfig, "find default datacenter", err)
+		}
+		c.Finder.SetDatacenter(dc)
+	}
+
+	return nil
+}
+
+// Close stops the keep-alive handlers and background goroutines and attempts
+// a clean logout from the various vCenter APIs. If the session cache is
+// enabled, the sessions are not terminated to be reused on the next start.
+//
+// Close respects the deadline of ctx and is safe to call repeatedly. If Close
+// fails, e.g. the deadline is exceeded, the logout is attempted again on the
+// next call. Once Close succeeded, further calls return nil. Sessions which are
+// already terminated are not considered a failure.
+func (c *Client) Close(ctx context.Context) error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	if err := c.close(ctx); err != nil {
+		return err
+	}
+	c.closed = true
+	return nil
+}
+
+// Logout is equivalent to Close with a background context.
+//
+// Deprecated: use Close to bound the logout with a deadline.
 func (c *Client) Logout() error {
-	ctx := context.Background()
+	return c.Close(context.Background())
+}
+
+func (c *Client) close(ctx context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	// keep-alive handlers might block on a request to an unresponsive vCenter
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		c.wg.Wait()
+		for _, h := range c.keepalives {
+			h.Stop()
+		}
+	}()
 
 	var result error
-	if err := c.REST.Logout(ctx); err != nil {
-		result = multierror.Append(result, err)
+	select {
+	case <-ctx.Done():
+		// the handlers stop shortly after the background goroutines, i.e. the
+		// logout is attempted anyway
+		result = multierror.Append(result, fmt.Errorf("stop keep-alive handlers: %w", ctx.Err()))
+	case <-stopped:
 	}
 
-	if err := c.SOAP.Logout(ctx); err != nil {
-		result = multierror.Append(result, err)
+	if c.cache != nil {
+		return result
 	}
 
+	if c.HasREST() {
+		if err := c.REST.Logout(ctx); err != nil && !rest.IsStatusError(err, http.StatusUnauthorized) {
+			result = multierror.Append(result, fmt.Errorf("logout REST session: %w", err))
+		}
+		c.metrics.setSessionActive(APIREST, false)
+	}
+
+	if err := c.SOAP.Logout(ctx); err != nil && !isNotAuthenticated(err) {
+		result = multierror.Append(result, fmt.Errorf("logout SOAP session: %w", err))
+	}
+	c.metrics.setSessionActive(APISOAP, false)
+
 	return result
 }
 
 // NewSOAP returns a vCenter SOAP API client with active keep-alive
-// configured via environment variables.
+// configured with the given options. If no options are specified, the client
+// is configured via environment variables (see WithEnv).
 //
 // Use Logout() to release resources and perform a clean logout from vCenter.
-func NewSOAP(ctx context.Context) (*govmomi.Client, error) {
-	var env Config
-	if err := envconfig.Process("", &env); err != nil {
-		return nil, err
+func NewSOAP(ctx context.Context, opts ...Option) (*govmomi.Client, error) {
+	o, err := newOptions(opts...)
+	if err != nil {
+		return nil, newError(ErrConfig, "configure vsphere SOAP client", err)
 	}
 
-	parsedURL, err := soap.ParseURL(env.Address)
+	loginCtx, cancel := loginContext(ctx, o)
+	defer cancel()
+
+	creds, err := getCredentials(loginCtx, o)
 	if err != nil {
-		return nil, err
+		return nil, newError(ErrCredentials, "create vsphere SOAP client", err)
 	}
 
-	// Read the username and password from the filesystem.
-	username, err := readKey(userFileKey)
+	// the keep-alive handler is stopped on Logout
+	c, err := newSOAP(loginCtx, detachedContext(ctx), o, creds, nil)
 	if err != nil {
-		return nil, err
+		return nil, newError(nil, "create vsphere SOAP client", err)
 	}
-	password, err := readKey(passwordFileKey)
+	return c, nil
+}
+
+// newSOAP creates a SOAP client and logs in within ctx. The keep-alive handler
+// uses the lifecycle context.
+func newSOAP(ctx, lifecycle context.Context, o *options, creds Credentials, lost SessionLostFunc) (*govmomi.Client, error) {
+	// the credentials are not part of the URL to not leak the password in
+	// errors and logs, e.g. through vim25.Client.URL()
+	u := *o.url
+	u.User = nil
+	c, err := soapWithKeepalive(ctx, lifecycle, &u, o, creds, lost)
 	if err != nil {
-		return nil, err
+		return nil, redact(err)
 	}
-	parsedURL.User = url.UserPassword(username, password)
-
-	return soapWithKeepalive(ctx, parsedURL, env.Insecure)
+	return c, nil
 }
 
-func soapWithKeepalive(ctx context.Context, url *url.URL, insecure bool) (*govmomi.Client, error) {
-	sc := soap.NewClient(url, insecure)
+func soapWithKeepalive(ctx, lifecycle context.Context, url *url.URL, o *options, creds Credentials, lost SessionLostFunc) (*govmomi.Client, error) {
+	sc := soap.NewClient(url, o.config.Insecure)
+	configureTransport(sc, o)
+
 	vc, err := vim25.NewClient(ctx, sc)
 	if err != nil {
 		return nil, err
 	}
-	vc.RoundTripper = keepalive.NewHandlerSOAP(sc, keepaliveInterval, soapKeepAliveHandler(ctx, vc))
+	h := keepalive.NewHandlerSOAP(soapMiddleware(sc, o), o.config.KeepaliveInterval, soapKeepAliveHandler(lifecycle, vc, o.metrics, lost))
+	vc.RoundTripper = h
 
-	// explicitly create session to activate keep-alive handler via Login
 	m := session.NewManager(vc)
-	err = m.Login(ctx, url.User)
-	if err != nil {
-		return nil, err
+	username := creds.Username
+
+	if o.cache != nil && o.cache.restoreSOAP(ctx, vc, m, username) {
+		// no Login involved to activate the keep-alive handler
+		h.Start()
+	} else {
+		// explicitly create session to activate keep-alive handler via Login
+		err = m.Login(ctx, creds.userinfo())
+		if err != nil {
+			return nil, err
+		}
+
+		if o.cache != nil {
+			if err = o.cache.saveSOAP(vc, username); err != nil {
+				logger.Get(ctx).Warn("save SOAP session to cache", zap.Error(err))
+			}
+		}
 	}
+	o.metrics.setSessionActive(APISOAP, true)
 
 	c := govmomi.Client{
 		Client:         vc,
@@ -162,15 +440,31 @@ func soapWithKeepalive(ctx context.Context, url *url.URL, insecure bool) (*govmo
 	return &c, nil
 }
 
-func soapKeepAliveHandler(ctx context.Context, c *vim25.Client) func() error {
+// soapKeepAliveHandler returns the SOAP keep-alive function. If lost is not
+// nil, it is called when the session is not authenticated anymore and errors
+// are not returned to keep the handler running. m may be nil.
+func soapKeepAliveHandler(ctx context.Context, c *vim25.Client, m *metrics, lost SessionLostFunc) func() error {
 	log := logger.Get(ctx)
 
 	return func() error {
 		log.Debug("executing SOAP keep-alive handler")
 		t, err := methods.GetCurrentTime(ctx, c)
+		m.observeKeepalive(APISOAP, err)
 		if err != nil {
 			log.Error("execute SOAP keep-alive handler", zap.Error(err))
-			return err
+			notAuthenticated := isNotAuthenticated(err)
+			if notAuthenticated {
+				m.setSessionActive(APISOAP, false)
+			}
+
+			if lost == nil {
+				return err
+			}
+
+			if notAuthenticated {
+				lost(APISOAP, err)
+			}
+			return nil
 		}
 
 		log.Debug("vCenter current time", zap.String("time", t.String()))
@@ -179,56 +473,107 @@ func soapKeepAliveHandler(ctx context.Context, c *vim25.Client) func() error {
 }
 
 // NewREST returns a vCenter REST (VAPI) API client with active keep-alive
-// configured via environment variables.
+// configured with the given options. If no options are specified, the client
+// is configured via environment variables (see WithEnv).
+//
+// The REST client inherits the TLS settings of the given SOAP client.
 //
 // Use Logout() to release resources and perform a clean logout from vCenter.
-func NewREST(ctx context.Context, vc *vim25.Client) (*rest.Client, error) {
-	var env Config
-	if err := envconfig.Process("", &env); err != nil {
-		return nil, err
-	}
-
-	parsedURL, err := soap.ParseURL(env.Address)
+func NewREST(ctx context.Context, vc *vim25.Client, opts ...Option) (*rest.Client, error) {
+	o, err := newOptions(opts...)
 	if err != nil {
-		return nil, err
+		return nil, newError(ErrConfig, "configure vsphere REST client", err)
 	}
 
-	// Read the username and password from the filesystem.
-	username, err := readKey(userFileKey)
+	loginCtx, cancel := loginContext(ctx, o)
+	defer cancel()
+
+	creds, err := getCredentials(loginCtx, o)
 	if err != nil {
-		return nil, err
+		return nil, newError(ErrCredentials, "create vsphere REST client", err)
 	}
-	password, err := readKey(passwordFileKey)
+
+	// the keep-alive handler is stopped on Logout
+	rc, _, err := newREST(loginCtx, detachedContext(ctx), vc, o, creds, nil)
 	if err != nil {
-		return nil, err
+		return nil, newError(nil, "create vsphere REST client", err)
 	}
-	parsedURL.User = url.UserPassword(username, password)
+	return rc, nil
+}
 
+// newREST creates a REST client and logs in within ctx. The keep-alive handler
+// uses the lifecycle context. The returned session client retrieves the session
+// of the REST client bypassing the keep-alive handler.
+func newREST(ctx, lifecycle context.Context, vc *vim25.Client, o *options, creds Credentials, lost SessionLostFunc) (*rest.Client, *rest.Client, error) {
 	rc := rest.NewClient(vc)
-	rc.Transport = keepalive.NewHandlerREST(rc, keepaliveInterval, restKeepAliveHandler(ctx, rc))
+	rc.Transport = restMiddleware(rc.Transport, o)
+
+	// the keep-alive handler treats POST requests to the session endpoint as
+	// login, i.e. checking the session through the handler deadlocks with a
+	// concurrent Stop on logout
+	sessionClient := rest.NewClient(vc)
+	sessionClient.Transport = rc.Transport
+
+	h := keepalive.NewHandlerREST(rc, o.config.KeepaliveInterval, restKeepAliveHandler(lifecycle, rc, sessionClient, o.metrics, lost))
+	rc.Transport = h
+
+	if o.cache != nil && o.cache.restoreREST(ctx, rc, sessionClient, creds.Username) {
+		// no Login involved to activate the keep-alive handler
+		h.Start()
+		o.metrics.setSessionActive(APIREST, true)
+		return rc, sessionClient, nil
+	}
 
-	// Login activates the keep-alive handler
-	if err = rc.Login(ctx, parsedURL.User); err != nil {
-		return nil, err
+	// Login activates the keep-alive handler, also if the login is rejected
+	if err := rc.Login(ctx, creds.userinfo()); err != nil {
+		h.Stop()
+		return nil, nil, redact(err)
 	}
-	return rc, nil
+	o.metrics.setSessionActive(APIREST, true)
+
+	if o.cache != nil {
+		if err := o.cache.saveREST(rc, creds.Username); err != nil {
+			logger.Get(ctx).Warn("save REST session to cache", zap.Error(err))
+		}
+	}
+	return rc, sessionClient, nil
 }
 
-func restKeepAliveHandler(ctx context.Context, restclient *rest.Client) func() error {
+// restKeepAliveHandler returns the REST keep-alive function which checks the
+// session of restclient with sessionClient bypassing the keep-alive
+// handler. If lost is not nil, it is called when the session is not
+// authenticated anymore and errors are not returned to keep the handler
+// running. m may be nil.
+func restKeepAliveHandler(ctx context.Context, restclient, sessionClient *rest.Client, m *metrics, lost SessionLostFunc) func() error {
 	log := logger.Get(ctx)
 
 	return func() error {
 		log.Debug("executing REST keep-alive handler")
-		s, err := restclient.Session(ctx)
+		sessionClient.SessionID(restclient.SessionID())
+		s, err := sessionClient.Session(ctx)
 		if err != nil {
+			m.observeKeepalive(APIREST, err)
 			// errors are not logged in govmomi keepalive handler
 			log.Error("execute REST keep-alive handler", zap.Error(err))
-			return err
+			if lost == nil {
+				return err
+			}
+			return nil
 		}
 		if s != nil {
+			m.observeKeepalive(APIREST, nil)
 			return nil
 		}
+
+		err = errors.New(http.StatusText(http.StatusUnauthorized))
+		m.observeKeepalive(APIREST, err)
+		m.setSessionActive(APIREST, false)
 		log.Error("execute REST keep-alive handler", zap.Error(err))
-		return errors.New(http.StatusText(http.StatusUnauthorized))
+		if lost == nil {
+			return err
+		}
+
+		lost(APIREST, err)
+		return nil
 	}
 }
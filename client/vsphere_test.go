@@ -2,14 +2,25 @@ package client
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/session/keepalive"
 	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
 	_ "github.com/vmware/govmomi/vapi/simulator"
 	"github.com/vmware/govmomi/vim25"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
 )
 
 func TestNewClient(t *testing.T) {
@@ -93,6 +104,11 @@ func TestNewClient(t *testing.T) {
 			assert.Assert(t, c.Tags != nil)
 			assert.Assert(t, c.Tasks != nil)
 			assert.Assert(t, c.Events != nil)
+			assert.Assert(t, c.Views != nil)
+			assert.Assert(t, c.Properties != nil)
+			assert.Assert(t, c.CustomFields != nil)
+			assert.Assert(t, c.Alarms != nil)
+			assert.Assert(t, c.Finder != nil)
 
 			err = c.Logout()
 			assert.NilError(t, err)
@@ -102,6 +118,198 @@ func TestNewClient(t *testing.T) {
 	})
 }
 
+func TestNewClientWithOptions(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		c, err := New(ctx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+		)
+		assert.NilError(t, err)
+		assert.Assert(t, c.SOAP != nil)
+		assert.Assert(t, c.REST != nil)
+
+		err = c.Logout()
+		assert.NilError(t, err)
+
+		return nil
+	})
+}
+
+func TestNewClientDatacenter(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		opts := []Option{
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+		}
+
+		t.Run("sets default datacenter of finder", func(t *testing.T) {
+			c, err := New(ctx, append(opts, WithDatacenter("DC0"))...)
+			assert.NilError(t, err)
+
+			dc, err := c.Finder.DefaultDatacenter(ctx)
+			assert.NilError(t, err)
+			assert.Equal(t, dc.Name(), "DC0")
+
+			// relative paths are resolved against the default datacenter
+			_, err = c.Finder.VirtualMachine(ctx, "DC0_H0_VM0")
+			assert.NilError(t, err)
+
+			assert.NilError(t, c.Logout())
+		})
+
+		t.Run("fails if datacenter does not exist", func(t *testing.T) {
+			c, err := New(ctx, append(opts, WithDatacenter("unknown"))...)
+			assert.ErrorContains(t, err, "find default datacenter")
+			assert.Assert(t, c == nil)
+
+			var cErr *Error
+			assert.Assert(t, errors.As(err, &cErr))
+			assert.Equal(t, cErr.Op, "find default datacenter")
+		})
+
+		return nil
+	})
+}
+
+func TestClient_Close(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		opts := []Option{
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+		}
+
+		t.Run("is idempotent", func(t *testing.T) {
+			c, err := New(ctx, opts...)
+			assert.NilError(t, err)
+
+			assert.NilError(t, c.Close(ctx))
+			assert.NilError(t, c.Close(ctx))
+			assert.NilError(t, c.Logout())
+		})
+
+		t.Run("retries logout after failure", func(t *testing.T) {
+			c, err := New(ctx, opts...)
+			assert.NilError(t, err)
+
+			expired, cancel := context.WithCancel(ctx)
+			cancel()
+			assert.Assert(t, c.Close(expired) != nil)
+			assert.Assert(t, c.sessionActive(ctx, APISOAP))
+			assert.Assert(t, c.sessionActive(ctx, APIREST))
+
+			assert.NilError(t, c.Close(ctx))
+			assert.Assert(t, !c.sessionActive(ctx, APISOAP))
+			assert.Assert(t, !c.sessionActive(ctx, APIREST))
+			assert.NilError(t, c.Close(ctx))
+		})
+
+		t.Run("ignores terminated sessions", func(t *testing.T) {
+			c, err := New(ctx, opts...)
+			assert.NilError(t, err)
+
+			s, err := c.SOAP.SessionManager.UserSession(ctx)
+			assert.NilError(t, err)
+			err = session.NewManager(vimclient).TerminateSession(ctx, []string{s.Key})
+			assert.NilError(t, err)
+
+			rc := rest.NewClient(vimclient)
+			rc.SessionID(c.REST.SessionID())
+			assert.NilError(t, rc.Logout(ctx))
+
+			assert.NilError(t, c.Close(ctx))
+		})
+
+		return nil
+	})
+}
+
+func TestNewClientLifecycle(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		newCtx, cancel := context.WithCancel(ctx)
+
+		c, err := New(newCtx,
+			WithURL(vimclient.URL().String()),
+			WithInsecure(true),
+			WithCredentials("user", "pass"),
+			WithKeepaliveInterval(50*time.Millisecond),
+			WithMetrics(prometheus.NewRegistry()),
+		)
+		assert.NilError(t, err)
+
+		// keep-alives must not depend on the constructor context
+		cancel()
+
+		keepalives := func(api API, result string) float64 {
+			return testutil.ToFloat64(c.metrics.keepalive.WithLabelValues(string(api), result))
+		}
+
+		poll.WaitOn(t, func(poll.LogT) poll.Result {
+			if keepalives(APISOAP, "success") < 3 || keepalives(APIREST, "success") < 3 {
+				return poll.Continue("waiting for keep-alives")
+			}
+			return poll.Success()
+		}, poll.WithTimeout(5*time.Second))
+
+		assert.Equal(t, keepalives(APISOAP, "failure"), 0.0)
+		assert.Equal(t, keepalives(APIREST, "failure"), 0.0)
+
+		assert.NilError(t, c.Close(ctx))
+		return nil
+	})
+}
+
+func TestNewClientSOAPOnly(t *testing.T) {
+	t.Run("detects standalone ESXi host", func(t *testing.T) {
+		model := simulator.ESX()
+		defer model.Remove()
+
+		err := model.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+			c, err := New(ctx,
+				WithURL(vimclient.URL().String()),
+				WithInsecure(true),
+				WithCredentials("user", "pass"),
+				WithDatacenter("ha-datacenter"),
+			)
+			assert.NilError(t, err)
+			assert.Assert(t, !c.HasREST())
+			assert.Assert(t, c.REST == nil)
+			assert.Assert(t, c.Tags == nil)
+			assert.Assert(t, c.CustomFields == nil)
+			assert.Assert(t, c.Alarms == nil)
+			assert.Assert(t, c.Finder != nil)
+
+			status, err := c.Check(ctx)
+			assert.NilError(t, err)
+			assert.Assert(t, status.Healthy)
+			assert.Assert(t, status.REST == nil)
+
+			assert.NilError(t, c.Close(ctx))
+			return nil
+		})
+		assert.NilError(t, err)
+	})
+
+	t.Run("disables REST for vCenter", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+			c, err := New(ctx,
+				WithURL(vimclient.URL().String()),
+				WithInsecure(true),
+				WithCredentials("user", "pass"),
+				WithoutREST(),
+			)
+			assert.NilError(t, err)
+			assert.Assert(t, !c.HasREST())
+			assert.Assert(t, c.Alarms != nil)
+
+			assert.NilError(t, c.Close(ctx))
+			return nil
+		})
+	})
+}
+
 func tempDir(t *testing.T) string {
 	t.Helper()
 
@@ -124,3 +332,59 @@ func tempDir(t *testing.T) string {
 
 	return dir
 }
+
+func Test_restKeepAliveHandler(t *testing.T) {
+	simulator.Run(func(ctx context.Context, vimclient *vim25.Client) error {
+		rc := rest.NewClient(vimclient)
+		send := restKeepAliveHandler(ctx, rc, rest.NewClient(vimclient), nil, nil)
+		h := keepalive.NewHandlerREST(rc, time.Hour, send)
+		rc.Transport = h
+
+		before := keepaliveGoroutines()
+		assert.NilError(t, rc.Login(ctx, simulator.DefaultLogin))
+		assert.Equal(t, keepaliveGoroutines(), before+1)
+
+		h.Stop()
+		waitForKeepaliveGoroutines(t, before)
+
+		// the session check must not restart the stopped handler which
+		// deadlocks with a concurrent Stop on logout
+		assert.NilError(t, send())
+		assert.Equal(t, keepaliveGoroutines(), before)
+
+		return nil
+	})
+}
+
+// keepaliveGoroutines returns the number of running keep-alive handler
+// goroutines
+func keepaliveGoroutines() int {
+	return goroutines("keepalive.(*handler).Start.func1(")
+}
+
+// goroutines returns the number of goroutines with the given function in their
+// stack
+func goroutines(function string) int {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return strings.Count(string(buf[:n]), function)
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// waitForKeepaliveGoroutines waits until the number of running keep-alive
+// handler goroutines is n, e.g. stopped goroutines might still be running
+func waitForKeepaliveGoroutines(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for keepaliveGoroutines() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d keep-alive goroutines, got %d", n, keepaliveGoroutines())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
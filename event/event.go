@@ -9,17 +9,26 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// EventSource creates event collectors and queries events. It is implemented by
+// *event.Manager and can be replaced with a fake in unit tests.
+type EventSource interface {
+	CreateCollectorForEvents(ctx context.Context, filter types.EventFilterSpec) (*event.HistoryCollector, error)
+	QueryEvents(ctx context.Context, filter types.EventFilterSpec) ([]types.BaseEvent, error)
+}
+
+var _ EventSource = (*event.Manager)(nil)
+
 // NewHistoryCollector creates a new event collector for the specified entity.
 // By default, events for the entity and all (indirect) children (if any) are
 // retrieved and event collection starts at "now".
-func NewHistoryCollector(ctx context.Context, mgr *event.Manager, entity types.ManagedObjectReference, filters ...Filter) (*event.HistoryCollector, error) {
+func NewHistoryCollector(ctx context.Context, src EventSource, entity types.ManagedObjectReference, filters ...Filter) (*event.HistoryCollector, error) {
 	f := defaultFilters
 	f = append(f, filters...)
 	spec, err := createSpec(entity, f)
 	if err != nil {
 		return nil, fmt.Errorf("create filter spec: %w", err)
 	}
-	return mgr.CreateCollectorForEvents(ctx, *spec)
+	return src.CreateCollectorForEvents(ctx, *spec)
 }
 
 func createSpec(entity types.ManagedObjectReference, filters []Filter) (*types.EventFilterSpec, error) {
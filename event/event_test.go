@@ -30,6 +30,33 @@ func Test_NewHistoryCollector(t *testing.T) {
 	})
 }
 
+// specRecorder is an EventSource which records the filter spec
+type specRecorder struct {
+	spec types.EventFilterSpec
+}
+
+func (r *specRecorder) CreateCollectorForEvents(_ context.Context, filter types.EventFilterSpec) (*event.HistoryCollector, error) {
+	r.spec = filter
+	return &event.HistoryCollector{}, nil
+}
+
+func (r *specRecorder) QueryEvents(_ context.Context, _ types.EventFilterSpec) ([]types.BaseEvent, error) {
+	return nil, nil
+}
+
+func Test_NewHistoryCollectorWithEventSource(t *testing.T) {
+	entity := types.ManagedObjectReference{Type: "Folder", Value: "group-d1"}
+
+	src := &specRecorder{}
+	collector, err := NewHistoryCollector(context.Background(), src, entity, WithEventTypeID([]string{"VmPoweredOnEvent"}))
+	assert.NilError(t, err)
+	assert.Assert(t, collector != nil)
+
+	assert.Equal(t, src.spec.Entity.Entity, entity)
+	assert.Equal(t, src.spec.Entity.Recursion, types.EventFilterSpecRecursionOptionAll)
+	assert.DeepEqual(t, src.spec.EventTypeId, []string{"VmPoweredOnEvent"})
+}
+
 func Test_createSpec(t *testing.T) {
 	const (
 		notNilErr = "must not be nil"
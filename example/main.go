@@ -38,7 +38,10 @@ func main() {
 		l.Fatal("could not create vsphere client", zap.Error(err))
 	}
 	defer func() {
-		_ = c.Logout()
+		// ctx is cancelled on shutdown
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = c.Close(closeCtx)
 	}()
 
 	// show how to use filters
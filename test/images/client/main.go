@@ -29,7 +29,7 @@ func main() {
 		l.Fatal("create client", zap.Error(err))
 	}
 	defer func() {
-		_ = c.Logout()
+		_ = c.Close(context.Background())
 	}()
 
 	v := c.SOAP.Version